@@ -55,7 +55,8 @@ REQUIRED="${5}"
 # Remote url for Kubernetes. If empty, will fetch kubernetes
 # from https://github.com/kubernetes/kubernetes.
 SOURCE_REMOTE="${6}"
-# maps to staging/k8s.io/src/${REPO} or the subdirectories from which the repo needs to be published
+# maps to staging/k8s.io/src/${REPO} or the subdirectories from which the repo needs to be published,
+# separated by ":". Each subdirectory can have a target path in the repo as "<dir>=<target>".
 SUBDIRS="${7}"
 # source repository organization name (eg. kubernetes)
 SOURCE_REPO_ORG="${8}"
@@ -181,9 +182,21 @@ if [ -z "${SKIP_TAGS}" ]; then
                --push-script ${PUSH_SCRIPT} \
                --dependencies "${DEPS}" \
                --mapping-output-file "../tag-${REPO}-{{.Tag}}-mapping" \
+               --mapping-store-dir .. \
                --publish-semver-tags \
                --skip-non-semver-tags="${SKIP_NON_SEMVER_TAGS}" \
                --semver-tags-base "${SEMVER_TAGS_BASE}" \
+               --source-host "${PUBLISHER_BOT_SOURCE_HOST:-github.com}" \
+               --source-org "${SOURCE_REPO_ORG}" \
+               --source-repo "${SOURCE_REPO_NAME}" \
+               --tag-message-template "${PUBLISHER_BOT_TAG_MESSAGE_TEMPLATE:-}" \
+               --tag-mappings "${PUBLISHER_BOT_TAG_MAPPINGS:-}" \
+               --min-tag-date "${PUBLISHER_BOT_MIN_TAG_DATE:-}" \
+               --max-tags "${PUBLISHER_BOT_MAX_TAGS:-0}" \
+               --only-newer-tags="${PUBLISHER_BOT_ONLY_NEWER_TAGS:-false}" \
+               --lightweight-tags="${PUBLISHER_BOT_LIGHTWEIGHT_TAGS:-false}" \
+               --reconcile-tags="${PUBLISHER_BOT_RECONCILE_TAGS:-false}" \
+               --rewrite-tags="${PUBLISHER_BOT_REWRITE_TAGS:-false}" \
                -alsologtostderr \
                "${EXTRA_ARGS[@]-}"
     if [ "${LAST_HEAD}" != "$(git rev-parse ${LAST_BRANCH})" ]; then
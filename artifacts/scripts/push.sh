@@ -15,8 +15,20 @@
 # limitations under the License.
 
 # This script sets up the .netrc file with the supplied token, then pushes to
-# the remote repo.
+# the remote repo. Without token file, git must be authenticated otherwise.
 # The script assumes that the working directory is the root of the repo.
+#
+# The netrc entry can be customized with the environment variables:
+#   PUBLISHER_BOT_NETRC_MACHINE: the host name (defaults to github.com)
+#   PUBLISHER_BOT_NETRC_LOGIN: the login the token is the password for
+#     (defaults to using the token as login)
+#
+# PUBLISHER_BOT_PUSH_PHASE selects one step of a two-phase publish:
+#   stage: push the branch to refs/publishing-bot/pending/<branch> and verify it
+#   promote: fast-forward the branch to the staged commit and drop the pending ref
+#   tags: push the tags created for the branch
+#   rollback: reset the branch to PUBLISHER_BOT_OLD_HEAD, or delete it if empty
+# Without phase, branch and tags are pushed at once.
 
 set -o errexit
 set -o nounset
@@ -27,16 +39,64 @@ if [ ! $# -eq 2 ]; then
     exit 1
 fi
 
-TOKEN="$(cat ${1})"
 BRANCH="${2}"
-readonly TOKEN BRANCH
+readonly BRANCH
+
+if [ -n "${1}" ]; then
+    TOKEN="$(cat ${1})"
+    MACHINE="${PUBLISHER_BOT_NETRC_MACHINE:-github.com}"
+    LOGIN="${PUBLISHER_BOT_NETRC_LOGIN:-}"
+    readonly TOKEN MACHINE LOGIN
 
-# set up github token in /netrc/.netrc
-echo "machine github.com login ${TOKEN}" > /netrc/.netrc
-cleanup_github_token() {
-    rm -rf /netrc/.netrc
-}
-trap cleanup_github_token EXIT SIGINT
+    # set up github token in /netrc/.netrc
+    if [ -n "${LOGIN}" ]; then
+        echo "machine ${MACHINE} login ${LOGIN} password ${TOKEN}" > /netrc/.netrc
+    else
+        echo "machine ${MACHINE} login ${TOKEN}" > /netrc/.netrc
+    fi
+    cleanup_github_token() {
+        rm -rf /netrc/.netrc
+    }
+    trap cleanup_github_token EXIT SIGINT
+    export HOME=/netrc
+fi
+
+PENDING="refs/publishing-bot/pending/${BRANCH}"
+PUSH_TAGS="../push-tags-$(basename "${PWD}")-${BRANCH/\//_}.sh"
+readonly PENDING PUSH_TAGS
 
-HOME=/netrc git push origin "${BRANCH}" --no-tags
-HOME=/netrc ../push-tags-$(basename "${PWD}")-${BRANCH/\//_}.sh
+case "${PUBLISHER_BOT_PUSH_PHASE:-}" in
+stage)
+    git push --force --no-tags origin "refs/heads/${BRANCH}:${PENDING}"
+    LOCAL="$(git rev-parse "refs/heads/${BRANCH}")"
+    REMOTE="$(git ls-remote origin "${PENDING}" | cut -f1)"
+    if [ "${LOCAL}" != "${REMOTE}" ]; then
+        echo "Staged ${PENDING} is at '${REMOTE}', expected ${LOCAL}."
+        exit 1
+    fi
+    ;;
+promote)
+    git push --no-tags origin "refs/heads/${BRANCH}:refs/heads/${BRANCH}"
+    git push --no-tags origin ":${PENDING}"
+    ;;
+tags)
+    ${PUSH_TAGS}
+    ;;
+rollback)
+    NEW_HEAD="$(git rev-parse "refs/heads/${BRANCH}")"
+    if [ -n "${PUBLISHER_BOT_OLD_HEAD:-}" ]; then
+        git push --no-tags --force-with-lease="refs/heads/${BRANCH}:${NEW_HEAD}" origin "${PUBLISHER_BOT_OLD_HEAD}:refs/heads/${BRANCH}"
+    else
+        git push --no-tags --force-with-lease="refs/heads/${BRANCH}:${NEW_HEAD}" origin ":refs/heads/${BRANCH}"
+    fi
+    git push --no-tags origin ":${PENDING}" || true
+    ;;
+"")
+    git push origin "${BRANCH}" --no-tags
+    ${PUSH_TAGS}
+    ;;
+*)
+    echo "unknown PUBLISHER_BOT_PUSH_PHASE ${PUBLISHER_BOT_PUSH_PHASE}"
+    exit 1
+    ;;
+esac
@@ -205,7 +205,7 @@ sync_repo() {
 
                 # does ${subdirectories} exist at ${k_branch_point_commit}? If not it was introduced to the branch via some fast-forward merge.
                 # we use the fast-forward merge commit's second parent (on master) as branch point.
-                subdirectory=${subdirectories}
+                subdirectory=${subdirectories%%=*}
                 if [ $(git ls-tree --name-only -r ${k_branch_point_commit} -- "${subdirectory}" | wc -l) = 0 ]; then
                     echo "Subdirectory ${subdirectory} did not exist at branch point ${k_branch_point_commit}. Looking for fast-forward merge introducing it."
                     last_with_subdir=$(git rev-list upstream/${src_branch} --first-parent --remove-empty -- "${subdirectory}" | tail -1)
@@ -529,7 +529,7 @@ sync_repo() {
     # create look-up file for collapsed upstream commits
     local repo=$(basename ${PWD})
     echo "Writing k8s.io/kubernetes commit lookup table to ../kube-commits-${repo}-${dst_branch/\//_}"
-    /collapsed-kube-commit-mapper --commit-message-tag $(echo ${source_repo_name} | sed 's/^./\L\u&/')-commit --source-branch refs/heads/upstream-branch > ../kube-commits-${repo}-${dst_branch/\//_}
+    /collapsed-kube-commit-mapper --commit-message-tag $(echo ${source_repo_name} | sed 's/^./\L\u&/')-commit --source-branch refs/heads/upstream-branch --mapping-store-dir .. --destination-repo ${repo} > ../kube-commits-${repo}-${dst_branch/\//_}
 }
 
 # for some PR branches cherry-picks fail. Put commits here where we only pick the whole merge as a single commit.
@@ -610,24 +610,13 @@ function filter-branch() {
     local commit_msg_tag="${1}"
     local subdirectories="${2}"
     local recursive_delete_pattern="${3}"
-    echo "Running git filter-branch ..."
+    echo "Running filter-branch ..."
 
-    if [[ "${subdirectories}" == *":"* ]]; then
-        echo "filter-branch cannot process multiple directories. Failing"
-        exit 1
-    fi
-
-    local index_filter=""
-    if [ -n "${recursive_delete_pattern}" ]; then
-        local patterns=()
-        local p=""
-        index_filter="git rm -q --cached --ignore-unmatch -r"
-        IFS=" " read -ra patterns <<<"${recursive_delete_pattern}"
-        for p in "${patterns[@]}"; do
-            index_filter+=" '${p}'"
-        done
-    fi
-    git filter-branch -f --index-filter "${index_filter}" --msg-filter 'awk 1 && echo && echo "'"${commit_msg_tag}"': ${GIT_COMMIT}"' --subdirectory-filter "${subdirectories}" -- ${4} ${5} >/dev/null
+    /filter-branch --commit-message-tag "${commit_msg_tag}" \
+                   --subdirectories "${subdirectories}" \
+                   --recursive-delete-patterns "${recursive_delete_pattern}" \
+                   -alsologtostderr \
+                   ${4} ${5} >/dev/null
 }
 
 function is-merge() {
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -66,7 +67,11 @@ The sorted output looks like this:
     <sha of H> <sha of H'>
     ...
 
+With --mapping-store-dir, the mapping is read from and stored into the given
+directory, such that only commits added since the last invocation are walked.
+
 Usage: %s --source-branch <source-branch> [-l] [--commit-message-tag <Commit-message-tag>]
+          [--mapping-store-dir <dir>] [--destination-repo <repo>]
 `, os.Args[0])
 	flag.PrintDefaults()
 }
@@ -75,6 +80,8 @@ func main() {
 	commitMsgTag := flag.String("commit-message-tag", "Kubernetes-commit", "the git commit message tag used to point back to source commits")
 	sourceBranch := flag.String("source-branch", "", "the source branch (fully qualified e.g. refs/remotes/origin/master) used as the filter-branch basis")
 	showMessage := flag.Bool("l", false, "list the commit message after the two hashes")
+	mappingStoreDir := flag.String("mapping-store-dir", "", "a directory to persist the mapping in, to only compute it incrementally")
+	dstRepo := flag.String("destination-repo", "", "the name of the destination repository in the mapping store (defaults to the name of the current directory)")
 
 	flag.Usage = Usage
 	flag.Parse()
@@ -108,20 +115,44 @@ func main() {
 	if err != nil {
 		glog.Fatalf("Failed to open upstream branch %s head: %v", *sourceBranch, err)
 	}
-	srcFirstParents, err := git.FirstParentList(r, srcHead)
-	if err != nil {
-		glog.Fatalf("Failed to get upstream branch %s first-parent list: %v", *sourceBranch, err)
-	}
 
-	// get first-parent commit list of HEAD
-	dstFirstParents, err := git.FirstParentList(r, dstHead)
-	if err != nil {
-		glog.Fatalf("Failed to get first-parent commit list for %s: %v", dstHead.Hash, err)
-	}
+	var sourceCommitToDstCommits map[plumbing.Hash]plumbing.Hash
+	if *mappingStoreDir != "" {
+		if *dstRepo == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				glog.Fatalf("Failed to get current working directory: %v", err)
+			}
+			*dstRepo = filepath.Base(wd)
+		}
+		store := git.NewMappingStore(*mappingStoreDir)
+		m, err := store.Load(*dstRepo, dstRef.Name().Short())
+		if err != nil {
+			glog.Fatalf("Failed to load mapping of %s: %v", *dstRepo, err)
+		}
+		if _, err := git.UpdateMapping(r, *commitMsgTag, m, srcHead, dstHead); err != nil {
+			glog.Fatalf("Failed to map upstream branch %s to HEAD: %v", *sourceBranch, err)
+		}
+		if err := store.Save(*dstRepo, dstRef.Name().Short(), m); err != nil {
+			glog.Fatalf("Failed to store mapping of %s: %v", *dstRepo, err)
+		}
+		sourceCommitToDstCommits = m.Commits
+	} else {
+		srcFirstParents, err := git.FirstParentList(r, srcHead)
+		if err != nil {
+			glog.Fatalf("Failed to get upstream branch %s first-parent list: %v", *sourceBranch, err)
+		}
 
-	sourceCommitToDstCommits, err := git.SourceCommitToDstCommits(r, *commitMsgTag, dstFirstParents, srcFirstParents)
-	if err != nil {
-		glog.Fatalf("Failed to map upstream branch %s to HEAD: %v", *sourceBranch, err)
+		// get first-parent commit list of HEAD
+		dstFirstParents, err := git.FirstParentList(r, dstHead)
+		if err != nil {
+			glog.Fatalf("Failed to get first-parent commit list for %s: %v", dstHead.Hash, err)
+		}
+
+		sourceCommitToDstCommits, err = git.SourceCommitToDstCommits(r, *commitMsgTag, dstFirstParents, srcFirstParents)
+		if err != nil {
+			glog.Fatalf("Failed to map upstream branch %s to HEAD: %v", *sourceBranch, err)
+		}
 	}
 
 	// print out a look-up table
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/golang/glog"
+	"k8s.io/publishing-bot/pkg/git"
+)
+
+func Usage() {
+	fmt.Fprintf(os.Stderr, `Rewrites the history of the given refs to only include the given
+subdirectories, like "git filter-branch --subdirectory-filter" does. A
+subdirectory can be moved to another path with "<dir>=<target>", "." being
+the root of the repository. Each
+rewritten commit gets a "<commit-message-tag>: <source commit>" line appended
+to its commit message. Only local branches are updated. If the checked out
+branch is rewritten, the index and the working tree are updated too.
+
+Usage: %s --subdirectories <dir>[=<target>][:<dir>[=<target>]...] [--commit-message-tag <Commit-message-tag>]
+          [--recursive-delete-patterns <patterns>] <ref>...
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	commitMsgTag := flag.String("commit-message-tag", "Kubernetes-commit", "the git commit message tag used to point back to source commits")
+	subdirectories := flag.String("subdirectories", "", "colon-separated list of directories to keep, optionally with a target path as <dir>=<target>")
+	recursiveDeletePatterns := flag.String("recursive-delete-patterns", "", "space-separated ls-files patterns to remove from every commit")
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	if *subdirectories == "" {
+		glog.Fatalf("subdirectories cannot be empty")
+	}
+	if flag.NArg() == 0 {
+		glog.Fatalf("at least one ref must be given")
+	}
+
+	var dirs []string
+	targets := map[string]string{}
+	for _, spec := range strings.Split(*subdirectories, ":") {
+		dir, target, found := strings.Cut(spec, "=")
+		dirs = append(dirs, dir)
+		if found {
+			targets[dir] = target
+		}
+	}
+
+	n, err := filterBranch(".", git.FilterOptions{
+		CommitMsgTag:            *commitMsgTag,
+		Dirs:                    dirs,
+		Targets:                 targets,
+		RecursiveDeletePatterns: strings.Fields(*recursiveDeletePatterns),
+	}, flag.Args())
+	if err != nil {
+		glog.Fatalf("Failed to filter %s: %v", strings.Join(flag.Args(), " "), err)
+	}
+	fmt.Printf("Rewrote %d commits.\n", n)
+}
+
+// filterBranch rewrites the given refs of the repository at dir. If the
+// checked out branch is rewritten, the index and the working tree are reset
+// to its new head, like "git filter-branch" does. It returns the number of
+// rewritten commits.
+func filterBranch(dir string, opts git.FilterOptions, args []string) (int, error) {
+	r, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open repo at %s: %w", dir, err)
+	}
+
+	var refs []plumbing.ReferenceName
+	for _, arg := range args {
+		ref, err := resolveRefName(r, arg)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve %q: %w", arg, err)
+		}
+		refs = append(refs, ref)
+	}
+
+	mapping, err := git.FilterBranch(r, opts, refs...)
+	if err != nil {
+		return 0, err
+	}
+	n := rewrittenCommits(mapping)
+
+	head, err := r.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if _, err := r.Worktree(); errors.Is(err, gogit.ErrIsBareRepository) || head.Type() != plumbing.SymbolicReference {
+		return n, nil
+	}
+	for _, ref := range refs {
+		if ref != head.Target() {
+			continue
+		}
+		if _, err := r.Reference(ref, false); errors.Is(err, plumbing.ErrReferenceNotFound) {
+			// all commits were dropped, nothing to check out
+			break
+		}
+		// go-git's hard reset would remove untracked files too
+		cmd := exec.Command("git", "read-tree", "-u", "--reset", "HEAD")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return 0, fmt.Errorf("failed to check out %s: %w: %s", ref, err, out)
+		}
+		break
+	}
+	return n, nil
+}
+
+// rewrittenCommits returns the number of distinct commits written, leaving
+// out source commits which were dropped or collapsed into an ancestor.
+func rewrittenCommits(mapping map[plumbing.Hash]plumbing.Hash) int {
+	written := map[plumbing.Hash]bool{}
+	for _, nh := range mapping {
+		if nh != plumbing.ZeroHash {
+			written[nh] = true
+		}
+	}
+	return len(written)
+}
+
+// resolveRefName expands a short ref name like "upstream/master" to its full name.
+func resolveRefName(r *gogit.Repository, name string) (plumbing.ReferenceName, error) {
+	for _, format := range []string{"%s", "refs/%s", "refs/heads/%s", "refs/remotes/%s", "refs/tags/%s"} {
+		ref, err := r.Reference(plumbing.ReferenceName(fmt.Sprintf(format, name)), false)
+		if err == nil {
+			return ref.Name(), nil
+		}
+	}
+	return "", plumbing.ErrReferenceNotFound
+}
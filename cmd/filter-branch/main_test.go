@@ -0,0 +1,79 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/publishing-bot/pkg/git"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s in %s failed: %v\n%s", strings.Join(args, " "), dir, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestFilterBranchCleanWorktree(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "master")
+	for p, content := range map[string]string{"staging/api/a.go": "a", "pkg/b.go": "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, p), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+	// dropped, as it does not touch staging/api
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "b.go"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "commit", "-q", "-a", "-m", "other")
+	if err := os.WriteFile(filepath.Join(dir, "untracked"), []byte("u"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := filterBranch(dir, git.FilterOptions{CommitMsgTag: "Kubernetes-commit", Dirs: []string{"staging/api"}}, []string{"master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 rewritten commit, got %d", n)
+	}
+
+	if status := gitCmd(t, dir, "status", "--porcelain"); status != "?? untracked" {
+		t.Errorf("expected only the untracked file in git status, got:\n%s", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.go")); err != nil {
+		t.Errorf("expected a.go at the root of the working tree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pkg")); !os.IsNotExist(err) {
+		t.Errorf("expected pkg to be removed from the working tree, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -27,6 +28,7 @@ import (
 	"github.com/golang/glog"
 	yaml "gopkg.in/yaml.v2"
 	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
 )
 
 var (
@@ -46,6 +48,12 @@ Command line flags override config values.
 func main() {
 	configFilePath := flag.String("config", "", "the config file in yaml format")
 	githubHost := flag.String("github-host", "", "the address of github (defaults to github.com)")
+	gitProvider := flag.String("provider", "", "the kind of git hosting at github-host: github, gitlab, gitea, forgejo or git (defaults to github)")
+	tokenFile := flag.String("token-file", "", "the file with the token to create repositories with")
+	githubAppID := flag.Int64("github-app-id", 0, "the GitHub App to create repositories as instead of with a token file")
+	githubAppInstallationID := flag.Int64("github-app-installation-id", 0, "the installation of the GitHub App in the target org")
+	githubAppPrivateKeyFile := flag.String("github-app-private-key-file", "", "the file with the PEM encoded private key of the GitHub App")
+	createRepos := flag.Bool("create-repos", false, "create missing destination repositories")
 	basePackage := flag.String("base-package", "", "the name of the package base (defaults to k8s.io when source repo is kubernetes, "+
 		"otherwise github-host/target-org)")
 	repoName := flag.String("source-repo", "", "the name of the source repository (eg. kubernetes)")
@@ -79,6 +87,21 @@ func main() {
 	if *githubHost != "" {
 		cfg.GithubHost = *githubHost
 	}
+	if *gitProvider != "" {
+		cfg.Provider = *gitProvider
+	}
+	if *tokenFile != "" {
+		cfg.TokenFile = *tokenFile
+	}
+	if *githubAppID != 0 {
+		cfg.GithubAppID = *githubAppID
+	}
+	if *githubAppInstallationID != 0 {
+		cfg.GithubAppInstallationID = *githubAppInstallationID
+	}
+	if *githubAppPrivateKeyFile != "" {
+		cfg.GithubAppPrivateKeyFile = *githubAppPrivateKeyFile
+	}
 	if *basePackage != "" {
 		cfg.BasePackage = *basePackage
 	}
@@ -134,14 +157,41 @@ func main() {
 		glog.Fatalf("Failed to create source repo directory %s: %v", BaseRepoPath, err)
 	}
 
+	tokenSource, err := provider.TokenSource(cfg)
+	if err != nil {
+		glog.Fatalf("Failed to set up authentication: %v", err)
+	}
+	prov, err := provider.New(cfg, tokenSource)
+	if err != nil {
+		glog.Fatalf("Failed to set up git provider: %v", err)
+	}
+
 	cloneSourceRepo(cfg)
 	for _, rule := range rules.Rules {
-		cloneForkRepo(cfg, rule.DestinationRepository)
+		if *createRepos {
+			ensureRepoExists(cfg, prov, rule.DestinationRepository)
+		}
+		cloneForkRepo(cfg, prov, rule.DestinationRepository)
+	}
+}
+
+// ensureRepoExists creates the destination repository if it cannot be listed.
+func ensureRepoExists(cfg *config.Config, prov provider.Provider, repoName string) {
+	repoLocation := prov.CloneURL(cfg.TargetOrg, repoName)
+	lsRemoteCmd := exec.Command("git", "ls-remote", "--heads", repoLocation)
+	lsRemoteCmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := lsRemoteCmd.Run(); err == nil {
+		return
+	}
+
+	glog.Infof("Creating repository %s/%s ...", cfg.TargetOrg, repoName)
+	if err := prov.CreateRepository(context.Background(), cfg.TargetOrg, repoName); err != nil {
+		glog.Fatalf("Failed to create repository %s/%s: %v", cfg.TargetOrg, repoName, err)
 	}
 }
 
-func cloneForkRepo(cfg *config.Config, repoName string) {
-	forkRepoLocation := fmt.Sprintf("https://%s/%s/%s", cfg.GithubHost, cfg.TargetOrg, repoName)
+func cloneForkRepo(cfg *config.Config, prov provider.Provider, repoName string) {
+	forkRepoLocation := prov.CloneURL(cfg.TargetOrg, repoName)
 	repoDir := filepath.Join(BaseRepoPath, repoName)
 
 	if _, err := os.Stat(repoDir); err == nil {
@@ -178,7 +228,7 @@ func run(c *exec.Cmd) {
 }
 
 func cloneSourceRepo(cfg *config.Config) {
-	repoLocation := fmt.Sprintf("https://%s/%s/%s", cfg.GithubHost, cfg.SourceOrg, cfg.SourceRepo)
+	repoLocation := cfg.SourceRepoURL()
 
 	if _, err := os.Stat(filepath.Join(BaseRepoPath, cfg.SourceRepo)); err == nil {
 		glog.Infof("Source repository %q already cloned, only setting remote", cfg.SourceRepo)
@@ -0,0 +1,290 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"gopkg.in/yaml.v2"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// skipUnchanged marks all repositories as skipped whose source directories
+// and upstream tags did not change since they were last published, and whose
+// dependencies are not reconstructed either. It returns the skipped
+// repositories with the reason.
+func (p *PublisherMunger) skipUnchanged(newUpstreamHeads map[string]plumbing.Hash) (map[string]string, error) {
+	repoDir := filepath.Join(p.baseRepoPath, p.config.SourceRepo)
+	r, err := gogit.PlainOpen(repoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo at %s: %w", repoDir, err)
+	}
+	p.tagsDigest, err = upstreamTagsDigest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	unchanged := map[string]string{}
+	for i := range p.reposRules.Rules {
+		repoRule := &p.reposRules.Rules[i]
+		if repoRule.Skip {
+			continue
+		}
+		reason, err := p.unchangedReason(r, repoRule, newUpstreamHeads, p.tagsDigest)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			unchanged[repoRule.DestinationRepository] = reason
+		}
+	}
+	skipped := skippableRepos(&p.reposRules, unchanged)
+
+	for i := range p.reposRules.Rules {
+		repoRule := &p.reposRules.Rules[i]
+		if reason, ok := skipped[repoRule.DestinationRepository]; ok {
+			p.plog.Infof("Skipping %s: %s", repoRule.DestinationRepository, reason)
+			repoRule.Skip = true
+		} else if reason, ok := unchanged[repoRule.DestinationRepository]; ok {
+			p.plog.Infof("Not skipping %s although %s, because a dependency changed", repoRule.DestinationRepository, reason)
+		}
+	}
+	return skipped, nil
+}
+
+// unchangedReason returns why the given repository does not have to be
+// reconstructed, or the empty string if it does. tagsDigest is the current
+// upstreamTagsDigest of the source repository.
+func (p *PublisherMunger) unchangedReason(r *gogit.Repository, repoRule *config.RepositoryRule, newUpstreamHeads map[string]plumbing.Hash, tagsDigest string) (string, error) {
+	digest, err := p.rulesDigest(repoRule)
+	if err != nil {
+		return "", err
+	}
+	bs, err := os.ReadFile(filepath.Join(p.baseRepoPath, rulesDigestFileName(repoRule.DestinationRepository)))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if string(bs) != digest {
+		return "", nil
+	}
+
+	// new or moved tags might point to commits not touching the source
+	// directories, but are still published by sync-tags.
+	bs, err = os.ReadFile(filepath.Join(p.baseRepoPath, tagsDigestFileName(repoRule.DestinationRepository)))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if string(bs) != tagsDigest {
+		return "", nil
+	}
+
+	var branches []string
+	for _, branchRule := range repoRule.Branches {
+		if p.skippedBranch(branchRule.Source.Branch) {
+			continue
+		}
+
+		newHead, ok := newUpstreamHeads[branchRule.Source.Branch]
+		if !ok {
+			return "", nil
+		}
+		bs, err := os.ReadFile(filepath.Join(p.baseRepoPath, publishedFileName(repoRule.DestinationRepository, branchRule.Name)))
+		if os.IsNotExist(err) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		oldHead := plumbing.NewHash(strings.TrimSpace(string(bs)))
+
+		dirs := branchRule.Source.Dirs
+		if len(dirs) == 0 {
+			dirs = []string{"."}
+		}
+		changed, err := dirsChanged(r, oldHead, newHead, dirs)
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			return "", nil
+		}
+		branches = append(branches, fmt.Sprintf("%s since %s", branchRule.Name, oldHead.String()[:7]))
+	}
+
+	if len(branches) == 0 {
+		return "no branches to publish", nil
+	}
+	return fmt.Sprintf("no upstream changes for branch(es) %s", strings.Join(branches, ", ")), nil
+}
+
+// rulesDigest returns a digest of all rules and configuration influencing the
+// construction of the given repository.
+func (p *PublisherMunger) rulesDigest(repoRule *config.RepositoryRule) (string, error) {
+	bs, err := yaml.Marshal(struct {
+		Rule                    *config.RepositoryRule
+		RecursiveDeletePatterns []string
+		SkipGomod               bool
+		SkipTags                bool
+		SkipNonSemverTags       bool
+		TagMessageTemplate      string
+		TagPolicy               config.TagPolicy
+		LightweightTags         bool
+		ReconcileTags           bool
+		RewriteTags             bool
+		BasePackage             string
+		GitDefaultBranch        string
+		SourceHost              string
+		SourceOrg               string
+		SourceRepo              string
+		TargetOrg               string
+	}{
+		repoRule, p.reposRules.RecursiveDeletePatterns, p.reposRules.SkipGomod, p.reposRules.SkipTags, p.reposRules.SkipNonSemverTags,
+		p.reposRules.TagMessageTemplate, p.reposRules.TagPolicy, p.reposRules.LightweightTags, p.reposRules.ReconcileTags, p.config.RewriteTags,
+		p.config.BasePackage, p.config.GitDefaultBranch, p.config.SourceRepoHost(), p.config.SourceOrg, p.config.SourceRepo, p.config.TargetOrg,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(bs)), nil
+}
+
+func rulesDigestFileName(repo string) string {
+	return fmt.Sprintf("rules-digest-%s", repo)
+}
+
+// upstreamTagsDigest returns a digest of all tags of the source repository
+// and the objects they point to.
+func upstreamTagsDigest(r *gogit.Repository) (string, error) {
+	iter, err := r.Tags()
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+	var tags []string
+	if err := iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, fmt.Sprintf("%s %s\n", ref.Name().Short(), ref.Hash()))
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+	sort.Strings(tags)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(tags, "")))), nil
+}
+
+func tagsDigestFileName(repo string) string {
+	return fmt.Sprintf("tags-digest-%s", repo)
+}
+
+// dirsChanged returns true if any of the given directories differs between the
+// old and the new commit. An unknown old commit counts as a change.
+func dirsChanged(r *gogit.Repository, oldHead, newHead plumbing.Hash, dirs []string) (bool, error) {
+	if oldHead == newHead {
+		return false, nil
+	}
+	oldCommit, err := r.CommitObject(oldHead)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	newCommit, err := r.CommitObject(newHead)
+	if err != nil {
+		return false, err
+	}
+
+	for _, dir := range dirs {
+		oldHash, err := dirHash(oldCommit, dir)
+		if err != nil {
+			return false, err
+		}
+		newHash, err := dirHash(newCommit, dir)
+		if err != nil {
+			return false, err
+		}
+		if oldHash != newHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dirHash returns the tree hash of dir in the given commit, or the zero hash
+// if it does not exist.
+func dirHash(c *object.Commit, dir string) (plumbing.Hash, error) {
+	dir = strings.Trim(filepath.Clean(dir), "/")
+	if dir == "." {
+		return c.TreeHash, nil
+	}
+	t, err := c.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	e, err := t.FindEntry(dir)
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return plumbing.ZeroHash, nil
+	} else if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return e.Hash, nil
+}
+
+// skippableRepos returns those unchanged repositories which do not depend,
+// transitively, on any repository that is reconstructed.
+func skippableRepos(rules *config.RepositoryRules, unchanged map[string]string) map[string]string {
+	skipped := map[string]string{}
+	for name, reason := range unchanged {
+		skipped[name] = reason
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, repoRule := range rules.Rules {
+			if _, ok := skipped[repoRule.DestinationRepository]; !ok {
+				continue
+			}
+			for _, branchRule := range repoRule.Branches {
+				for _, dep := range branchRule.Dependencies {
+					if !dependencySkipped(rules, skipped, dep.Repository) {
+						delete(skipped, repoRule.DestinationRepository)
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return skipped
+}
+
+func dependencySkipped(rules *config.RepositoryRules, skipped map[string]string, repo string) bool {
+	if _, ok := skipped[repo]; ok {
+		return true
+	}
+	// repos skipped by the rules are not reconstructed either
+	for _, repoRule := range rules.Rules {
+		if repoRule.DestinationRepository == repo {
+			return repoRule.Skip
+		}
+	}
+	return false
+}
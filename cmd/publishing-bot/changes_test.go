@@ -0,0 +1,259 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+func TestDirsChanged(t *testing.T) {
+	dir := t.TempDir()
+	r, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(files map[string]string) plumbing.Hash {
+		t.Helper()
+		for p, content := range files {
+			if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(p)), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, p), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.AddGlob("."); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+		h, err := w.Commit("commit", &gogit.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	a := commit(map[string]string{"staging/foo/a.go": "a", "staging/bar/b.go": "b"})
+	b := commit(map[string]string{"staging/bar/b.go": "changed"})
+
+	tests := []struct {
+		name     string
+		old, new plumbing.Hash
+		dirs     []string
+		want     bool
+	}{
+		{"same commit", a, a, []string{"staging/foo"}, false},
+		{"untouched dir", a, b, []string{"staging/foo"}, false},
+		{"touched dir", a, b, []string{"staging/bar"}, true},
+		{"one of multiple dirs touched", a, b, []string{"staging/foo", "staging/bar/"}, true},
+		{"root", a, b, []string{"."}, true},
+		{"missing dir", a, b, []string{"staging/missing"}, false},
+		{"unknown old commit", plumbing.NewHash("0123456789012345678901234567890123456789"), b, []string{"staging/foo"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dirsChanged(r, tt.old, tt.new, tt.dirs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("dirsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkippableRepos(t *testing.T) {
+	branch := func(deps ...string) []config.BranchRule {
+		br := config.BranchRule{Name: "master"}
+		for _, d := range deps {
+			br.Dependencies = append(br.Dependencies, config.Dependency{Repository: d, Branch: "master"})
+		}
+		return []config.BranchRule{br}
+	}
+	rules := &config.RepositoryRules{Rules: []config.RepositoryRule{
+		{DestinationRepository: "apimachinery", Branches: branch()},
+		{DestinationRepository: "api", Branches: branch("apimachinery")},
+		{DestinationRepository: "client-go", Branches: branch("apimachinery", "api")},
+		{DestinationRepository: "code-generator", Branches: branch()},
+		{DestinationRepository: "legacy", Skip: true, Branches: branch()},
+		{DestinationRepository: "metrics", Branches: branch("legacy")},
+	}}
+
+	got := skippableRepos(rules, map[string]string{
+		"apimachinery":   "unchanged",
+		"client-go":      "unchanged",
+		"code-generator": "unchanged",
+		"metrics":        "unchanged",
+	})
+	want := map[string]string{
+		"apimachinery":   "unchanged",
+		"code-generator": "unchanged",
+		"metrics":        "unchanged",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("skippableRepos() = %v, want %v", got, want)
+	}
+}
+
+func TestRulesDigest(t *testing.T) {
+	repoRule := &config.RepositoryRule{DestinationRepository: "api", Branches: []config.BranchRule{{Name: "master"}}}
+	digest := func(cfg config.Config, rules config.RepositoryRules) string {
+		t.Helper()
+		p := &PublisherMunger{config: &cfg, reposRules: rules}
+		d, err := p.rulesDigest(repoRule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	base := digest(config.Config{}, config.RepositoryRules{})
+
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		rules config.RepositoryRules
+	}{
+		{"tag message template", config.Config{}, config.RepositoryRules{TagMessageTemplate: "{{.Tag}}"}},
+		{"tag policy", config.Config{}, config.RepositoryRules{TagPolicy: config.TagPolicy{MaxTagsPerRun: 1}}},
+		{"lightweight tags", config.Config{}, config.RepositoryRules{LightweightTags: true}},
+		{"reconcile tags", config.Config{}, config.RepositoryRules{ReconcileTags: true}},
+		{"rewrite tags", config.Config{RewriteTags: true}, config.RepositoryRules{}},
+		{"base package", config.Config{BasePackage: "example.com"}, config.RepositoryRules{}},
+		{"default branch", config.Config{GitDefaultBranch: "main"}, config.RepositoryRules{}},
+		{"source host", config.Config{SourceHost: "github.example.com"}, config.RepositoryRules{}},
+		{"source org", config.Config{SourceOrg: "kcp-dev"}, config.RepositoryRules{}},
+		{"source repo", config.Config{SourceRepo: "kcp"}, config.RepositoryRules{}},
+		{"target org", config.Config{TargetOrg: "kcp-dev"}, config.RepositoryRules{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if digest(tt.cfg, tt.rules) == base {
+				t.Errorf("rulesDigest() did not change")
+			}
+		})
+	}
+}
+
+func TestUnchangedReason(t *testing.T) {
+	dir := t.TempDir()
+	r, err := gogit.PlainInit(filepath.Join(dir, "kubernetes"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(p, content string) plumbing.Hash {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, "kubernetes", filepath.Dir(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "kubernetes", p), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Add(p); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+		h, err := w.Commit("commit", &gogit.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	published := commit("staging/api/a.go", "a")
+
+	repoRule := &config.RepositoryRule{
+		DestinationRepository: "api",
+		Branches: []config.BranchRule{{
+			Name:   "master",
+			Source: config.Source{Branch: "master", Dirs: []string{"staging/api"}},
+		}},
+	}
+	p := &PublisherMunger{config: &config.Config{SourceRepo: "kubernetes"}, baseRepoPath: dir}
+	if err := os.WriteFile(filepath.Join(dir, publishedFileName("api", "master")), []byte(published.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeDigests := func() string {
+		t.Helper()
+		digest, err := p.rulesDigest(repoRule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, rulesDigestFileName("api")), []byte(digest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tagsDigest, err := upstreamTagsDigest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, tagsDigestFileName("api")), []byte(tagsDigest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return tagsDigest
+	}
+	unchanged := func(heads map[string]plumbing.Hash) bool {
+		t.Helper()
+		tagsDigest, err := upstreamTagsDigest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reason, err := p.unchangedReason(r, repoRule, heads, tagsDigest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reason != ""
+	}
+	writeDigests()
+
+	head := commit("pkg/b.go", "b")
+	heads := map[string]plumbing.Hash{"master": head}
+	if !unchanged(heads) {
+		t.Errorf("repository constructed although only other directories changed")
+	}
+
+	if _, err := r.CreateTag("v1.0.0", head, nil); err != nil {
+		t.Fatal(err)
+	}
+	if unchanged(heads) {
+		t.Errorf("repository skipped although a tag was added")
+	}
+	writeDigests()
+	if !unchanged(heads) {
+		t.Errorf("repository constructed although the tag was published")
+	}
+
+	p.reposRules.LightweightTags = true
+	if unchanged(heads) {
+		t.Errorf("repository skipped although lightweight tags were enabled")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const checkpointFileName = "checkpoint.json"
+
+// checkpoint records the branches constructed by a run that did not finish,
+// such that a retry for the same upstream heads continues with the branch that
+// failed. It is safe for concurrent use.
+type checkpoint struct {
+	fileName string
+
+	lock  sync.Mutex
+	state checkpointState
+}
+
+type checkpointState struct {
+	// UpstreamKey identifies the upstream branch heads the checkpoint is for.
+	UpstreamKey string `json:"upstreamKey"`
+	// Branches are the constructed branches by "<repo>/<branch>".
+	Branches map[string]checkpointBranch `json:"branches,omitempty"`
+}
+
+type checkpointBranch struct {
+	// RulesDigest is the digest of the repository rules the branch was
+	// constructed with.
+	RulesDigest string `json:"rulesDigest"`
+	// Head is the constructed local branch head.
+	Head string `json:"head"`
+	// Report is the report of the construction, restored when skipping it.
+	Report BranchReport `json:"report"`
+}
+
+// upstreamKey returns a digest of the given upstream branch heads.
+func upstreamKey(heads map[string]plumbing.Hash) string {
+	names := make([]string, 0, len(heads))
+	for name := range heads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s %s\n", name, heads[name])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// loadCheckpoint reads the checkpoint from the given file. A missing file or a
+// checkpoint for other upstream heads results in an empty checkpoint.
+func loadCheckpoint(fileName, key string) (*checkpoint, error) {
+	c := &checkpoint{fileName: fileName, state: checkpointState{UpstreamKey: key}}
+
+	bs, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state checkpointState
+	if err := json.Unmarshal(bs, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", fileName, err)
+	}
+	if state.UpstreamKey == key {
+		c.state = state
+	}
+	return c, nil
+}
+
+func checkpointKey(repo, branch string) string {
+	return repo + "/" + branch
+}
+
+// lookup returns the checkpointed branch if it was constructed with the given
+// rules digest.
+func (c *checkpoint) lookup(repo, branch, rulesDigest string) (checkpointBranch, bool) {
+	if c == nil {
+		return checkpointBranch{}, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	b, ok := c.state.Branches[checkpointKey(repo, branch)]
+	if !ok || b.RulesDigest != rulesDigest {
+		return checkpointBranch{}, false
+	}
+	return b, true
+}
+
+// tags returns the tags created for the checkpointed branches of repo.
+func (c *checkpoint) tags(repo string) []string {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var tags []string
+	for key, b := range c.state.Branches {
+		if strings.HasPrefix(key, repo+"/") {
+			tags = append(tags, b.Report.TagsCreated...)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// record adds a constructed branch and writes the checkpoint.
+func (c *checkpoint) record(repo, branch string, b checkpointBranch) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.state.Branches == nil {
+		c.state.Branches = map[string]checkpointBranch{}
+	}
+	c.state.Branches[checkpointKey(repo, branch)] = b
+
+	bs, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.fileName + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.fileName)
+}
+
+// clear removes the checkpoint after a successful run.
+func (c *checkpoint) clear() error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.state.Branches = nil
+	if err := os.Remove(c.fileName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), checkpointFileName)
+	heads := map[string]plumbing.Hash{
+		"master":       plumbing.NewHash("1111111111111111111111111111111111111111"),
+		"release-1.30": plumbing.NewHash("2222222222222222222222222222222222222222"),
+	}
+	key := upstreamKey(heads)
+
+	c, err := loadCheckpoint(fileName, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.lookup("api", "master", "digest"); ok {
+		t.Errorf("expected empty checkpoint")
+	}
+	if err := c.record("api", "master", checkpointBranch{
+		RulesDigest: "digest",
+		Head:        "abc",
+		Report:      BranchReport{TagsCreated: []string{"v0.30.1"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.record("api", "release-1.30", checkpointBranch{
+		RulesDigest: "digest",
+		Head:        "def",
+		Report:      BranchReport{TagsCreated: []string{"v0.30.0"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a retry for the same upstream heads
+	c, err = loadCheckpoint(fileName, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := c.lookup("api", "master", "digest"); !ok || b.Head != "abc" {
+		t.Errorf("expected checkpointed api/master at abc, got %+v, %v", b, ok)
+	}
+	if _, ok := c.lookup("api", "master", "changed"); ok {
+		t.Errorf("expected no checkpoint for changed rules")
+	}
+	if _, ok := c.lookup("apimachinery", "master", "digest"); ok {
+		t.Errorf("expected no checkpoint for apimachinery")
+	}
+	if tags, want := c.tags("api"), []string{"v0.30.0", "v0.30.1"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tags)
+	}
+	if tags := c.tags("apimachinery"); tags != nil {
+		t.Errorf("expected no tags for apimachinery, got %v", tags)
+	}
+
+	// new upstream commits invalidate the checkpoint
+	heads["master"] = plumbing.NewHash("3333333333333333333333333333333333333333")
+	other, err := loadCheckpoint(fileName, upstreamKey(heads))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.lookup("api", "master", "digest"); ok {
+		t.Errorf("expected no checkpoint for other upstream heads")
+	}
+
+	if err := c.clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+		t.Errorf("expected checkpoint file to be removed, got: %v", err)
+	}
+	if err := c.clear(); err != nil {
+		t.Errorf("expected clearing twice to succeed, got: %v", err)
+	}
+}
@@ -16,12 +16,34 @@ limitations under the License.
 
 package config
 
+import "fmt"
+
+// Git providers, i.e. the kinds of git hosting the bot can publish to.
+const (
+	ProviderGitHub  = "github"
+	ProviderGitLab  = "gitlab"
+	ProviderGitea   = "gitea"
+	ProviderForgejo = "forgejo"
+	// ProviderGit is a plain git remote without API, i.e. without issue
+	// reporting and repository creation.
+	ProviderGit = "git"
+)
+
 // Config is how we are configured to talk to github.
 type Config struct {
-	// GithubHost is the address for github.
+	// GithubHost is the address for github, or the git provider in general.
 	// Defaults to github.com
 	GithubHost string `yaml:"github-host"`
 
+	// Provider is the kind of git hosting at GithubHost: github, gitlab, gitea,
+	// forgejo or git. Defaults to github.
+	Provider string `yaml:"provider,omitempty"`
+
+	// CloneURLTemplate is a text/template of the URL of a repository, with the
+	// fields .Host, .Org and .Repo.
+	// Defaults to https://{{.Host}}/{{.Org}}/{{.Repo}}.git
+	CloneURLTemplate string `yaml:"clone-url-template,omitempty"`
+
 	// BasePackage is the base package name for this repo.
 	// Defaults to k8s.io when SourceOrg is kubernetes, otherwise, defaults
 	// to ${GithubHost}/${TargetOrg}
@@ -36,9 +58,29 @@ type Config struct {
 	// the source repo org name, e.g. "kubernetes"
 	SourceOrg string `yaml:"source-org"`
 
+	// SourceHost is the address of the git hosting of the source repo.
+	// Defaults to GithubHost with the github provider, to github.com otherwise.
+	SourceHost string `yaml:"source-host,omitempty"`
+
 	// the file with the clear-text github token
 	TokenFile string `yaml:"token-file,omitempty"`
 
+	// the GitHub App to authenticate as instead of with a token file. Short-lived
+	// installation tokens are minted and refreshed from the app's private key.
+	GithubAppID             int64 `yaml:"github-app-id,omitempty"`
+	GithubAppInstallationID int64 `yaml:"github-app-installation-id,omitempty"`
+	// the file with the PEM encoded private key of the GitHub App
+	GithubAppPrivateKeyFile string `yaml:"github-app-private-key-file,omitempty"`
+
+	// the file with the secret of the GitHub webhook for the source repo. The
+	// /webhook endpoint is disabled if empty.
+	WebhookSecretFile string `yaml:"webhook-secret-file,omitempty"`
+
+	// IsolateFailures continues with independent repositories if a repository
+	// fails to construct or publish. The failed repository and the repositories
+	// depending on it are not published.
+	IsolateFailures bool `yaml:"isolate-failures,omitempty"`
+
 	// the file that contain the repository rules
 	RulesFile string `yaml:"rules-file"`
 
@@ -48,10 +90,87 @@ type Config struct {
 	// A github issue number to report errors
 	GithubIssue int `yaml:"github-issue,omitempty"`
 
+	// RewriteTags allows deleting and recreating tags at the destination repos
+	// whose source tag was deleted or moved, if reconcile-tags is enabled in
+	// the rules. Without it, such tags are only reported.
+	RewriteTags bool `yaml:"rewrite-tags,omitempty"`
+
+	// RepositoryIssues enables one tracking issue per destination repository,
+	// opened when the repository fails to publish and closed when it publishes
+	// again. The issues are found by IssueLabel and a stable title.
+	RepositoryIssues bool `yaml:"repository-issues,omitempty"`
+
+	// the label of the per-repository tracking issues. Defaults to publishing-bot.
+	IssueLabel string `yaml:"issue-label,omitempty"`
+
+	// Notifications are the sinks notified about failed and recovered runs
+	// and new tags.
+	Notifications []Notification `yaml:"notifications,omitempty"`
+
 	// BasePublishScriptPath determine the base path where we will look for a
 	// publishing scripts in the source repo. It defaults to ./publishing_scripts'.
 	BasePublishScriptPath string `yaml:"base-publish-script-path,omitempty"`
 
 	// name of the default git branch in the repo. defaults to master
 	GitDefaultBranch string `yaml:"git-default-branch,omitempty"`
+
+	// RunHistory is the number of runs kept on disk with their logs and
+	// reports, and served at /runs. Defaults to 20.
+	RunHistory int `yaml:"run-history,omitempty"`
+
+	// LogFormat is the format of run.log and of the command output on stdout:
+	// text or json, i.e. JSON lines with repo, branch, phase and command.
+	// Defaults to text.
+	LogFormat string `yaml:"log-format,omitempty"`
+
+	// the maximal number of branches of independent repositories to construct
+	// concurrently. Defaults to 1.
+	ConstructWorkers int `yaml:"construct-workers,omitempty"`
+}
+
+// SourceRepoHost returns the address of the git hosting of the source repo.
+func (c *Config) SourceRepoHost() string {
+	if c.SourceHost != "" {
+		return c.SourceHost
+	}
+	if (c.Provider == "" || c.Provider == ProviderGitHub) && c.GithubHost != "" {
+		return c.GithubHost
+	}
+	return "github.com"
+}
+
+// SourceRepoURL returns the URL to clone the source repo from.
+func (c *Config) SourceRepoURL() string {
+	return fmt.Sprintf("https://%s/%s/%s", c.SourceRepoHost(), c.SourceOrg, c.SourceRepo)
+}
+
+// Kinds of notification sinks.
+const (
+	NotificationSlack   = "slack"
+	NotificationWebhook = "webhook"
+	NotificationEmail   = "email"
+)
+
+// Notification configures one notification sink.
+type Notification struct {
+	// Type is slack, webhook or email.
+	Type string `yaml:"type"`
+
+	// Events are the events sent to the sink: failure, recovery and new-tag.
+	// Defaults to all.
+	Events []string `yaml:"events,omitempty"`
+
+	// the file with the URL of the Slack incoming webhook or the generic JSON
+	// webhook. URL can be used instead if it is no secret.
+	URLFile string `yaml:"url-file,omitempty"`
+	URL     string `yaml:"url,omitempty"`
+
+	// the SMTP server as host:port, the sender and the recipients of emails.
+	SMTPServer string   `yaml:"smtp-server,omitempty"`
+	From       string   `yaml:"from,omitempty"`
+	To         []string `yaml:"to,omitempty"`
+	// the login for SMTP PLAIN auth, and the file with the password. No auth is
+	// done if empty.
+	SMTPUsername     string `yaml:"smtp-username,omitempty"`
+	SMTPPasswordFile string `yaml:"smtp-password-file,omitempty"`
 }
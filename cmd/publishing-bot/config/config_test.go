@@ -0,0 +1,42 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import "testing"
+
+func TestSourceRepoURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"default", Config{}, "https://github.com/kubernetes/kubernetes"},
+		{"github enterprise", Config{GithubHost: "github.example.com"}, "https://github.example.com/kubernetes/kubernetes"},
+		{"gitlab target", Config{Provider: ProviderGitLab, GithubHost: "gitlab.example.com"}, "https://github.com/kubernetes/kubernetes"},
+		{"git target", Config{Provider: ProviderGit, GithubHost: "git.example.com"}, "https://github.com/kubernetes/kubernetes"},
+		{"explicit source host", Config{Provider: ProviderGitea, GithubHost: "gitea.example.com", SourceHost: "gitea.example.com"}, "https://gitea.example.com/kubernetes/kubernetes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.SourceOrg = "kubernetes"
+			tt.cfg.SourceRepo = "kubernetes"
+			if got := tt.cfg.SourceRepoURL(); got != tt.want {
+				t.Errorf("SourceRepoURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
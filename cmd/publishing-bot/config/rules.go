@@ -9,9 +9,12 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/golang/glog"
@@ -42,6 +45,11 @@ type Source struct {
 	// Directories from the repo root
 	// If Dirs is present, it is given preference over Dir
 	Dirs []string `yaml:"dirs,omitempty"`
+	// Targets maps directories from Dirs to a path in the destination repo,
+	// e.g. "staging/src/foo: ." and "hack/tools: hack". Without a target, a
+	// single directory is published at the root of the destination repo and
+	// multiple directories are published at their source path.
+	Targets map[string]string `yaml:"targets,omitempty"`
 }
 
 func (c Source) String() string {
@@ -49,7 +57,36 @@ func (c Source) String() string {
 	if repo == "" {
 		repo = "<source>"
 	}
-	return fmt.Sprintf("[repository %s, branch %s, subdir {%s}]", repo, c.Branch, c.Dir)
+	dirs := c.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{c.Dir}
+	}
+	return fmt.Sprintf("[repository %s, branch %s, subdir {%s}]", repo, c.Branch, strings.Join(dirs, ","))
+}
+
+// DirTarget returns the path in the destination repo dir is published to.
+func (c Source) DirTarget(dir string) string {
+	if t, ok := c.Targets[dir]; ok {
+		return path.Clean(strings.Trim(t, "/"))
+	}
+	if len(c.Dirs) == 1 {
+		return "."
+	}
+	return path.Clean(strings.Trim(dir, "/"))
+}
+
+// DirSpecs returns Dirs in the "<dir>[=<target>]" format understood by the
+// publishing scripts.
+func (c Source) DirSpecs() []string {
+	specs := make([]string, 0, len(c.Dirs))
+	for _, d := range c.Dirs {
+		if _, ok := c.Targets[d]; ok {
+			specs = append(specs, d+"="+c.DirTarget(d))
+		} else {
+			specs = append(specs, d)
+		}
+	}
+	return specs
 }
 
 type BranchRule struct {
@@ -69,12 +106,56 @@ type BranchRule struct {
 	SmokeTest string `yaml:"smoke-test,omitempty"` // a multiline bash script
 }
 
+// Prerelease filters of tag mappings.
+const (
+	TagPrereleasesInclude = "include"
+	TagPrereleasesExclude = "exclude"
+	TagPrereleasesOnly    = "only"
+)
+
+// TagMapping maps source tags to tags published at the destination repo.
+type TagMapping struct {
+	// Source is a regular expression matching the source tags, e.g.
+	// ^v1\.(\d+\.\d+)$.
+	Source string `yaml:"source" json:"source"`
+	// Destination is a text/template of the destination tag, with the
+	// fields .Tag for the source tag, .Match for the submatches of Source by
+	// index and .Groups for its named submatches, e.g. v0.{{index .Match 1}}
+	// or staging/foo/{{.Tag}}.
+	Destination string `yaml:"destination" json:"destination"`
+	// Prereleases is include (the default), exclude or only, for source
+	// tags with a semver prerelease version like v1.2.0-rc.1.
+	Prereleases string `yaml:"prereleases,omitempty" json:"prereleases,omitempty"`
+}
+
+// TagPolicy selects the source tags published in a run. Without a policy, all
+// source tags are published.
+type TagPolicy struct {
+	// MinDate skips source tags older than this date, as YYYY-MM-DD or in
+	// RFC 3339 format.
+	MinDate string `yaml:"min-date,omitempty"`
+	// MaxTagsPerRun is the maximum number of source tags published per run,
+	// oldest first. The remaining tags are published in later runs.
+	MaxTagsPerRun int `yaml:"max-tags-per-run,omitempty"`
+	// OnlyNewerThanPublished skips source tags not newer than the newest
+	// source tag already published at the destination repo.
+	OnlyNewerThanPublished bool `yaml:"only-newer-than-published,omitempty"`
+}
+
 // a collection of publishing rules for a single destination repo.
 type RepositoryRule struct {
 	DestinationRepository string       `yaml:"destination"`
 	Branches              []BranchRule `yaml:"branches"`
 	// the value to use as vX in vX.Y.Z published at the destination repo
 	DestinationTagBase string `yaml:"destination-tag-base,omitempty"`
+	// TagMessageTemplate overrides the tag message template of the
+	// repository rules for this destination repo.
+	TagMessageTemplate string `yaml:"tag-message-template,omitempty"`
+	// TagMappings map source tags to destination tags. Every mapping
+	// matching a source tag publishes a destination tag. If set, they replace
+	// the default <source-repo>-X.Y.Z and vX.Y.Z tags, DestinationTagBase and
+	// SkipNonSemverTags.
+	TagMappings []TagMapping `yaml:"tag-mappings,omitempty"`
 	// SmokeTest applies to all branches
 	SmokeTest string `yaml:"smoke-test,omitempty"` // a multiline bash script
 	Library   bool   `yaml:"library,omitempty"`
@@ -91,6 +172,23 @@ type RepositoryRules struct {
 	SkipNonSemverTags bool             `yaml:"skip-non-semver-tags,omitempty"`
 	Rules             []RepositoryRule `yaml:"rules"`
 
+	// TagMessageTemplate is a text/template of the message of the annotated
+	// tags created in the destination repos, with the fields .SourceTag,
+	// .DestinationTag, .SourceHost, .SourceOrg, .SourceRepo, .SourceCommit and
+	// .DestinationCommit. Defaults to the template of sync-tags.
+	TagMessageTemplate string `yaml:"tag-message-template,omitempty"`
+
+	// TagPolicy selects the source tags published in a run.
+	TagPolicy TagPolicy `yaml:"tag-policy,omitempty"`
+	// LightweightTags publishes lightweight source tags too, dated by the
+	// committer date of the tagged commit. The destination tags are annotated
+	// as usual.
+	LightweightTags bool `yaml:"lightweight-tags,omitempty"`
+	// ReconcileTags reports tags published before whose source tag was
+	// deleted or moved to another commit. They are only deleted or recreated
+	// if the bot runs with rewrite-tags.
+	ReconcileTags bool `yaml:"reconcile-tags,omitempty"`
+
 	// ls-files patterns like: */BUILD *.ext pkg/foo.go Makefile
 	RecursiveDeletePatterns []string `yaml:"recursive-delete-patterns"`
 	// a valid go version string like 1.10.2 or 1.10
@@ -99,6 +197,15 @@ type RepositoryRules struct {
 	DefaultGoVersion *string `yaml:"default-go-version,omitempty"`
 }
 
+// TagMessageTemplateFor returns the tag message template for the destination
+// repo of r, empty for the default of sync-tags.
+func (rules *RepositoryRules) TagMessageTemplateFor(r *RepositoryRule) string {
+	if r.TagMessageTemplate != "" {
+		return r.TagMessageTemplate
+	}
+	return rules.TagMessageTemplate
+}
+
 // LoadRules loads the repository rules either from the remote HTTP location or
 // a local file path.
 func LoadRules(ruleFile string) (*RepositoryRules, error) {
@@ -264,14 +371,115 @@ func fixDeprecatedFields(rules *RepositoryRules) {
 	}
 }
 
+// validateSourceDirs validates that the targets of the source directories
+// of every branch are valid and do not overlap.
+func validateSourceDirs(rules *RepositoryRules) (errs []error) {
+	glog.Infof("validating source directories")
+	for i := range rules.Rules {
+		rule := rules.Rules[i]
+		for j := range rule.Branches {
+			src := rule.Branches[j].Source
+			for dir, target := range src.Targets {
+				if !slices.Contains(src.Dirs, dir) {
+					errs = append(errs, fmt.Errorf("target %q of repository %q, branch %q is for unknown directory %q", target, rule.DestinationRepository, rule.Branches[j].Name, dir))
+				}
+			}
+
+			targets := map[string]string{}
+			for _, dir := range src.Dirs {
+				target := src.DirTarget(dir)
+				if path.IsAbs(target) || target == ".." || strings.HasPrefix(target, "../") {
+					errs = append(errs, fmt.Errorf("target %q of directory %q in repository %q, branch %q is outside of the repository", target, dir, rule.DestinationRepository, rule.Branches[j].Name))
+					continue
+				}
+				for otherDir, other := range targets {
+					if targetsOverlap(target, other) {
+						errs = append(errs, fmt.Errorf("directories %q and %q in repository %q, branch %q have overlapping targets %q and %q", otherDir, dir, rule.DestinationRepository, rule.Branches[j].Name, other, target))
+					}
+				}
+				targets[dir] = target
+			}
+		}
+	}
+	return errs
+}
+
+// targetsOverlap returns true if a and b are equal or one is inside the
+// other. The repository root does not overlap with any other target, the
+// content of deeper targets replaces what is published at the root.
+func targetsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if a == "." || b == "." {
+		return false
+	}
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+func validateTagMessageTemplates(rules *RepositoryRules) (errs []error) {
+	glog.Infof("validating tag message templates")
+	if _, err := template.New("tag-message-template").Parse(rules.TagMessageTemplate); err != nil {
+		errs = append(errs, fmt.Errorf("invalid tag message template: %w", err))
+	}
+	for _, r := range rules.Rules {
+		if _, err := template.New("tag-message-template").Parse(r.TagMessageTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid tag message template of %q: %w", r.DestinationRepository, err))
+		}
+	}
+	return errs
+}
+
+func validateTagMappings(rules *RepositoryRules) (errs []error) {
+	glog.Infof("validating tag mappings")
+	for _, r := range rules.Rules {
+		for i, m := range r.TagMappings {
+			if _, err := regexp.Compile(m.Source); err != nil {
+				errs = append(errs, fmt.Errorf("invalid source of tag mapping %d of %q: %w", i, r.DestinationRepository, err))
+			}
+			if m.Destination == "" {
+				errs = append(errs, fmt.Errorf("empty destination of tag mapping %d of %q", i, r.DestinationRepository))
+			} else if _, err := template.New("destination").Parse(m.Destination); err != nil {
+				errs = append(errs, fmt.Errorf("invalid destination of tag mapping %d of %q: %w", i, r.DestinationRepository, err))
+			}
+			switch m.Prereleases {
+			case "", TagPrereleasesInclude, TagPrereleasesExclude, TagPrereleasesOnly:
+			default:
+				errs = append(errs, fmt.Errorf("invalid prereleases %q of tag mapping %d of %q, must be %s, %s or %s", m.Prereleases, i, r.DestinationRepository, TagPrereleasesInclude, TagPrereleasesExclude, TagPrereleasesOnly))
+			}
+		}
+	}
+	return errs
+}
+
+func validateTagPolicy(rules *RepositoryRules) (errs []error) {
+	glog.Infof("validating tag policy")
+	if d := rules.TagPolicy.MinDate; d != "" {
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			if _, err := time.Parse(time.RFC3339, d); err != nil {
+				errs = append(errs, fmt.Errorf("invalid min-date %q of tag policy, must be YYYY-MM-DD or RFC 3339", d))
+			}
+		}
+	}
+	if rules.TagPolicy.MaxTagsPerRun < 0 {
+		errs = append(errs, fmt.Errorf("invalid max-tags-per-run %d of tag policy, must not be negative", rules.TagPolicy.MaxTagsPerRun))
+	}
+	return errs
+}
+
 func Validate(rules *RepositoryRules) error {
 	errs := []error{}
 
 	errs = append(errs, validateRepoOrder(rules)...)
 	errs = append(errs, validateGoVersions(rules)...)
+	errs = append(errs, validateTagMessageTemplates(rules)...)
+	errs = append(errs, validateTagMappings(rules)...)
+	errs = append(errs, validateTagPolicy(rules)...)
 
 	fixDeprecatedFields(rules)
 
+	errs = append(errs, validateSourceDirs(rules)...)
+
 	msgs := []string{}
 	for _, err := range errs {
 		if err != nil {
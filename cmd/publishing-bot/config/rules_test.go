@@ -79,3 +79,129 @@ func TestValidateGoVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateSourceDirs(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  Source
+		isValid bool
+	}{
+		{"single dir", Source{Dirs: []string{"staging/src/foo"}}, true},
+		{"multiple dirs", Source{Dirs: []string{"staging/src/foo", "hack/tools"}}, true},
+		{"nested dirs", Source{Dirs: []string{"hack", "hack/tools"}}, false},
+		{
+			"root and subdirectory target",
+			Source{Dirs: []string{"staging/src/foo", "hack/tools"}, Targets: map[string]string{"staging/src/foo": ".", "hack/tools": "hack"}},
+			true,
+		},
+		{
+			"same target",
+			Source{Dirs: []string{"staging/src/foo", "staging/src/bar"}, Targets: map[string]string{"staging/src/foo": "pkg", "staging/src/bar": "pkg/"}},
+			false,
+		},
+		{
+			"nested targets",
+			Source{Dirs: []string{"staging/src/foo", "staging/src/bar"}, Targets: map[string]string{"staging/src/foo": "pkg", "staging/src/bar": "pkg/bar"}},
+			false,
+		},
+		{"two roots", Source{Dirs: []string{"foo", "bar"}, Targets: map[string]string{"foo": ".", "bar": "."}}, false},
+		{"target outside of repo", Source{Dirs: []string{"foo"}, Targets: map[string]string{"foo": "../foo"}}, false},
+		{"target for unknown dir", Source{Dirs: []string{"foo"}, Targets: map[string]string{"bar": "bar"}}, false},
+	}
+
+	for _, test := range tests {
+		rules := &RepositoryRules{Rules: []RepositoryRule{{
+			DestinationRepository: "foo",
+			Branches:              []BranchRule{{Name: "master", Source: test.source}},
+		}}}
+		errs := validateSourceDirs(rules)
+		if test.isValid && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %v", test.name, errs)
+		}
+		if !test.isValid && len(errs) == 0 {
+			t.Errorf("%s: expected errors, got none", test.name)
+		}
+	}
+}
+
+func TestValidateTagMessageTemplates(t *testing.T) {
+	tests := []struct {
+		name       string
+		global     string
+		repository string
+		isValid    bool
+	}{
+		{"default", "", "", true},
+		{"global", "{{.SourceRepo}} {{.SourceTag}}", "", true},
+		{"repository", "", "Based on {{.SourceCommit}}", true},
+		{"invalid global", "{{.SourceTag", "", false},
+		{"invalid repository", "", "{{if .SourceTag}}", false},
+	}
+
+	for _, test := range tests {
+		rules := &RepositoryRules{
+			TagMessageTemplate: test.global,
+			Rules:              []RepositoryRule{{DestinationRepository: "foo", TagMessageTemplate: test.repository}},
+		}
+		errs := validateTagMessageTemplates(rules)
+		if test.isValid && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %v", test.name, errs)
+		}
+		if !test.isValid && len(errs) == 0 {
+			t.Errorf("%s: expected errors, got none", test.name)
+		}
+	}
+}
+
+func TestValidateTagMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping TagMapping
+		isValid bool
+	}{
+		{"semver", TagMapping{Source: `^v1\.(\d+\.\d+)$`, Destination: "v0.{{index .Match 1}}"}, true},
+		{"submodule", TagMapping{Source: `^v`, Destination: "staging/foo/{{.Tag}}", Prereleases: TagPrereleasesExclude}, true},
+		{"invalid source", TagMapping{Source: `^v(`, Destination: "{{.Tag}}"}, false},
+		{"empty destination", TagMapping{Source: `^v`}, false},
+		{"invalid destination", TagMapping{Source: `^v`, Destination: "{{.Tag"}, false},
+		{"invalid prereleases", TagMapping{Source: `^v`, Destination: "{{.Tag}}", Prereleases: "never"}, false},
+	}
+
+	for _, test := range tests {
+		rules := &RepositoryRules{Rules: []RepositoryRule{{
+			DestinationRepository: "foo",
+			TagMappings:           []TagMapping{test.mapping},
+		}}}
+		errs := validateTagMappings(rules)
+		if test.isValid && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %v", test.name, errs)
+		}
+		if !test.isValid && len(errs) == 0 {
+			t.Errorf("%s: expected errors, got none", test.name)
+		}
+	}
+}
+
+func TestValidateTagPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  TagPolicy
+		isValid bool
+	}{
+		{"empty", TagPolicy{}, true},
+		{"date", TagPolicy{MinDate: "2023-01-01", MaxTagsPerRun: 5, OnlyNewerThanPublished: true}, true},
+		{"timestamp", TagPolicy{MinDate: "2023-01-01T00:00:00Z"}, true},
+		{"invalid date", TagPolicy{MinDate: "01/01/2023"}, false},
+		{"negative max tags", TagPolicy{MaxTagsPerRun: -1}, false},
+	}
+
+	for _, test := range tests {
+		errs := validateTagPolicy(&RepositoryRules{TagPolicy: test.policy})
+		if test.isValid && len(errs) > 0 {
+			t.Errorf("%s: expected no errors, got %v", test.name, errs)
+		}
+		if !test.isValid && len(errs) == 0 {
+			t.Errorf("%s: expected errors, got none", test.name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// constructJob is the construction of one branch of a destination repository.
+type constructJob struct {
+	repoRule   *config.RepositoryRule
+	branchRule config.BranchRule
+
+	// deps are the jobs which have to finish before this one can start.
+	deps []*constructJob
+	// repos are the destination repositories whose checkouts the job
+	// modifies, i.e. its own and those of its dependencies.
+	repos []string
+}
+
+func (j *constructJob) String() string {
+	return fmt.Sprintf("%s/%s", j.repoRule.DestinationRepository, j.branchRule.Name)
+}
+
+// constructJobs returns the jobs to construct all non-skipped branches of the
+// rules. A job depends on the jobs of the branches listed in its dependencies,
+// and on the previous branch of the same repository because new branches are
+// started from the default branch.
+func constructJobs(rules *config.RepositoryRules, skippedBranch func(string) bool) []*constructJob {
+	var jobs []*constructJob
+	byBranch := map[config.Dependency]*constructJob{}
+	for i := range rules.Rules {
+		repoRule := &rules.Rules[i]
+		if repoRule.Skip {
+			continue
+		}
+
+		var prev *constructJob
+		for _, branchRule := range repoRule.Branches {
+			if skippedBranch(branchRule.Source.Branch) {
+				continue
+			}
+
+			j := &constructJob{
+				repoRule:   repoRule,
+				branchRule: branchRule,
+				repos:      []string{repoRule.DestinationRepository},
+			}
+			if prev != nil {
+				j.deps = append(j.deps, prev)
+			}
+			for _, dep := range branchRule.Dependencies {
+				j.repos = append(j.repos, dep.Repository)
+				// dependencies of skipped repos or branches are used as published
+				if dj, ok := byBranch[dep]; ok {
+					j.deps = append(j.deps, dj)
+				}
+			}
+			sort.Strings(j.repos)
+
+			byBranch[config.Dependency{Repository: repoRule.DestinationRepository, Branch: branchRule.Name}] = j
+			jobs = append(jobs, j)
+			prev = j
+		}
+	}
+	return jobs
+}
+
+// runConstructJobs runs the jobs with at most the given number of workers.
+// A job is started when all its dependencies have finished and no running job
+// touches any of its repositories. Jobs are started in the given order. After
+// the first failure no new jobs are started, and the error is returned once the
+// running jobs have finished.
+func runConstructJobs(jobs []*constructJob, workers int, run func(*constructJob) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	type result struct {
+		job *constructJob
+		err error
+	}
+	results := make(chan result)
+
+	pending := append([]*constructJob(nil), jobs...)
+	done := map[*constructJob]bool{}
+	locked := map[string]bool{}
+	running := 0
+	var firstErr error
+
+	ready := func(j *constructJob) bool {
+		for _, d := range j.deps {
+			if !done[d] {
+				return false
+			}
+		}
+		for _, repo := range j.repos {
+			if locked[repo] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		for i := 0; firstErr == nil && i < len(pending) && running < workers; {
+			j := pending[i]
+			if !ready(j) {
+				i++
+				continue
+			}
+			pending = append(pending[:i], pending[i+1:]...)
+			for _, repo := range j.repos {
+				locked[repo] = true
+			}
+			running++
+			go func() {
+				results <- result{j, run(j)}
+			}()
+		}
+
+		if running == 0 {
+			break
+		}
+
+		res := <-results
+		running--
+		for _, repo := range res.job.repos {
+			delete(locked, repo)
+		}
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		done[res.job] = true
+	}
+
+	if firstErr == nil && len(pending) > 0 {
+		return fmt.Errorf("cannot construct %v: unsatisfiable dependencies", pending)
+	}
+	return firstErr
+}
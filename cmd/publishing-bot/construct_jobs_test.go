@@ -0,0 +1,144 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+func testConstructRules() *config.RepositoryRules {
+	branch := func(name string, deps ...string) config.BranchRule {
+		br := config.BranchRule{Name: name, Source: config.Source{Branch: name}}
+		for _, d := range deps {
+			br.Dependencies = append(br.Dependencies, config.Dependency{Repository: d, Branch: name})
+		}
+		return br
+	}
+	return &config.RepositoryRules{Rules: []config.RepositoryRule{
+		{DestinationRepository: "apimachinery", Branches: []config.BranchRule{branch("master"), branch("release-1.0")}},
+		{DestinationRepository: "api", Branches: []config.BranchRule{branch("master", "apimachinery")}},
+		{DestinationRepository: "client-go", Branches: []config.BranchRule{branch("master", "apimachinery", "api")}},
+		{DestinationRepository: "code-generator", Branches: []config.BranchRule{branch("master")}},
+		{DestinationRepository: "skipped", Skip: true, Branches: []config.BranchRule{branch("master")}},
+	}}
+}
+
+func TestConstructJobs(t *testing.T) {
+	jobs := constructJobs(testConstructRules(), func(b string) bool { return false })
+
+	got := map[string][]string{}
+	for _, j := range jobs {
+		deps := []string{}
+		for _, d := range j.deps {
+			deps = append(deps, d.String())
+		}
+		got[j.String()] = deps
+	}
+	want := map[string][]string{
+		"apimachinery/master":      {},
+		"apimachinery/release-1.0": {"apimachinery/master"},
+		"api/master":               {"apimachinery/master"},
+		"client-go/master":         {"apimachinery/master", "api/master"},
+		"code-generator/master":    {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected job dependencies %v, expected %v", got, want)
+	}
+
+	jobs = constructJobs(testConstructRules(), func(b string) bool { return b == "master" })
+	if len(jobs) != 1 || jobs[0].String() != "apimachinery/release-1.0" || len(jobs[0].deps) != 0 {
+		t.Errorf("unexpected jobs %v with skipped master branches", jobs)
+	}
+}
+
+func TestRunConstructJobs(t *testing.T) {
+	jobs := constructJobs(testConstructRules(), func(b string) bool { return false })
+
+	var lock sync.Mutex
+	var order []string
+	finished := map[string]bool{}
+	busy := map[string]bool{}
+	maxRunning, running := 0, 0
+	err := runConstructJobs(jobs, 3, func(j *constructJob) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, d := range j.deps {
+			if !finished[d.String()] {
+				return fmt.Errorf("%s started before its dependency %s", j, d)
+			}
+		}
+		for _, repo := range j.repos {
+			if busy[repo] {
+				return fmt.Errorf("%s started while %s is in use", j, repo)
+			}
+			busy[repo] = true
+		}
+		running++
+		maxRunning = max(maxRunning, running)
+
+		lock.Unlock()
+		// let other workers start
+		lock.Lock()
+
+		running--
+		for _, repo := range j.repos {
+			busy[repo] = false
+		}
+		finished[j.String()] = true
+		order = append(order, j.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != len(jobs) {
+		t.Errorf("expected %d jobs to run, got %v", len(jobs), order)
+	}
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent jobs, got %d", maxRunning)
+	}
+}
+
+func TestRunConstructJobsFailure(t *testing.T) {
+	jobs := constructJobs(testConstructRules(), func(b string) bool { return false })
+
+	var lock sync.Mutex
+	var ran []string
+	err := runConstructJobs(jobs, 1, func(j *constructJob) error {
+		lock.Lock()
+		defer lock.Unlock()
+		ran = append(ran, j.String())
+		if j.String() == "api/master" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("expected the job error, got %v", err)
+	}
+	sort.Strings(ran)
+	if want := []string{"api/master", "apimachinery/master", "apimachinery/release-1.0"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("unexpected jobs run %v, expected %v", ran, want)
+	}
+}
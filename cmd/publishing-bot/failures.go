@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// Phases a repository can fail in.
+const (
+	PhaseConstruct = "construct"
+	PhasePublish   = "publish"
+)
+
+// RepositoryFailure describes why a destination repository was not published
+// when failures are isolated.
+type RepositoryFailure struct {
+	Repository string `json:"repository"`
+	// Branch is the branch that failed, empty if a dependency failed.
+	Branch string `json:"branch,omitempty"`
+	// Phase is construct or publish.
+	Phase string `json:"phase,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Dependency is the failed repository this repository depends on.
+	Dependency string `json:"dependency,omitempty"`
+}
+
+// failureTracker records failed repositories, and fails their transitive
+// dependents with them. It is safe for concurrent use. A nil tracker, i.e.
+// without isolation, has no failures.
+type failureTracker struct {
+	// dependents maps a repository to the repositories depending on it in
+	// any branch.
+	dependents map[string][]string
+
+	lock     sync.Mutex
+	failed   map[string]bool
+	failures []RepositoryFailure
+}
+
+func newFailureTracker(rules *config.RepositoryRules) *failureTracker {
+	t := &failureTracker{
+		dependents: map[string][]string{},
+		failed:     map[string]bool{},
+	}
+	for _, repoRule := range rules.Rules {
+		seen := map[string]bool{}
+		for _, branchRule := range repoRule.Branches {
+			for _, dep := range branchRule.Dependencies {
+				if !seen[dep.Repository] {
+					seen[dep.Repository] = true
+					t.dependents[dep.Repository] = append(t.dependents[dep.Repository], repoRule.DestinationRepository)
+				}
+			}
+		}
+	}
+	return t
+}
+
+// fail records the failure of the given branch, and fails all repositories
+// transitively depending on repo.
+func (t *failureTracker) fail(repo, branch, phase string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.failed[repo] {
+		return
+	}
+	t.failed[repo] = true
+	t.failures = append(t.failures, RepositoryFailure{Repository: repo, Branch: branch, Phase: phase, Error: err.Error()})
+
+	queue := []string{repo}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		for _, d := range t.dependents[r] {
+			if t.failed[d] {
+				continue
+			}
+			t.failed[d] = true
+			t.failures = append(t.failures, RepositoryFailure{Repository: d, Dependency: r})
+			queue = append(queue, d)
+		}
+	}
+}
+
+// isFailed returns true if repo or one of its dependencies failed.
+func (t *failureTracker) isFailed(repo string) bool {
+	if t == nil {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.failed[repo]
+}
+
+// list returns the failures in the order they were recorded.
+func (t *failureTracker) list() []RepositoryFailure {
+	if t == nil {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]RepositoryFailure(nil), t.failures...)
+}
+
+// err returns an error summarizing the failed repositories, or nil.
+func (t *failureTracker) err() error {
+	if t == nil {
+		return nil
+	}
+	failures := t.list()
+	if len(failures) == 0 {
+		return nil
+	}
+	repos := make([]string, 0, len(failures))
+	for _, f := range failures {
+		repos = append(repos, f.Repository)
+	}
+	sort.Strings(repos)
+	return fmt.Errorf("%d repositories failed: %s", len(repos), strings.Join(repos, ", "))
+}
+
+// formatFailures renders the failures as markdown list.
+func formatFailures(failures []RepositoryFailure) string {
+	var sb strings.Builder
+	sb.WriteString("Failed repositories:\n")
+	for _, f := range failures {
+		switch {
+		case f.Dependency != "":
+			fmt.Fprintf(&sb, "- `%s`: dependency `%s` failed\n", f.Repository, f.Dependency)
+		case f.Branch == "":
+			fmt.Fprintf(&sb, "- `%s`: %s failed: %s\n", f.Repository, f.Phase, f.Error)
+		default:
+			fmt.Fprintf(&sb, "- `%s`: %s of branch `%s` failed: %s\n", f.Repository, f.Phase, f.Branch, f.Error)
+		}
+	}
+	return sb.String()
+}
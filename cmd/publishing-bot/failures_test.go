@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFailureTracker(t *testing.T) {
+	// apimachinery <- api <- client-go, apimachinery <- code-generator
+	tracker := newFailureTracker(testConstructRules())
+
+	if err := tracker.err(); err != nil {
+		t.Errorf("expected no error without failures, got %v", err)
+	}
+
+	tracker.fail("api", "master", PhaseConstruct, errors.New("boom"))
+	tracker.fail("client-go", "master", PhaseConstruct, errors.New("already failed"))
+
+	for repo, want := range map[string]bool{"apimachinery": false, "api": true, "client-go": true, "code-generator": false} {
+		if got := tracker.isFailed(repo); got != want {
+			t.Errorf("expected isFailed(%q) = %v, got %v", repo, want, got)
+		}
+	}
+
+	want := []RepositoryFailure{
+		{Repository: "api", Branch: "master", Phase: PhaseConstruct, Error: "boom"},
+		{Repository: "client-go", Dependency: "api"},
+	}
+	if got := tracker.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected failures %+v, expected %+v", got, want)
+	}
+	if err := tracker.err(); err == nil || err.Error() != "2 repositories failed: api, client-go" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if got, want := formatFailures(tracker.list()), "Failed repositories:\n"+
+		"- `api`: construct of branch `master` failed: boom\n"+
+		"- `client-go`: dependency `api` failed\n"; got != want {
+		t.Errorf("unexpected formatting %q, expected %q", got, want)
+	}
+
+	var disabled *failureTracker
+	if disabled.isFailed("api") || disabled.list() != nil || disabled.err() != nil {
+		t.Errorf("expected a nil tracker to have no failures")
+	}
+}
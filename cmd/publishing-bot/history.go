@@ -0,0 +1,189 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const (
+	runsDirName       = "runs"
+	runReportName     = "report.json"
+	runLogName        = "run.log"
+	defaultRunHistory = 20
+)
+
+var runIDPattern = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}(-[0-9]+)?$`)
+
+// errRunNotFound is returned for unknown run IDs.
+var errRunNotFound = errors.New("run not found")
+
+// RunSummary describes a run in the history.
+type RunSummary struct {
+	ID         string        `json:"id"`
+	StartTime  string        `json:"startTime"`
+	Successful bool          `json:"successful"`
+	Error      string        `json:"error,omitempty"`
+	Triggers   []*RunTrigger `json:"triggers,omitempty"`
+}
+
+// runHistory stores the reports and logs of the last runs in a directory, one
+// sub-directory per run named by its start time. It is safe for concurrent
+// use.
+type runHistory struct {
+	dir string
+	// max is the number of runs kept.
+	max int
+
+	lock sync.RWMutex
+}
+
+func newRunHistory(dir string, maxRuns int) *runHistory {
+	return &runHistory{dir: dir, max: maxRuns}
+}
+
+// add stores a finished run, and removes the oldest runs beyond the maximum.
+// It returns the ID of the run.
+func (h *runHistory) add(report *RunReport, logs string) (string, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return "", err
+	}
+	base := report.StartTime.UTC().Format("20060102-150405")
+	id := base
+	for i := 1; ; i++ {
+		err := os.Mkdir(filepath.Join(h.dir, id), 0o755)
+		if err == nil {
+			break
+		} else if !os.IsExist(err) {
+			return "", err
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	bs, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(h.dir, id, runReportName), bs, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(h.dir, id, runLogName), []byte(logs), 0o644); err != nil {
+		return "", err
+	}
+
+	ids, err := h.ids()
+	if err != nil {
+		return "", err
+	}
+	for len(ids) > h.max {
+		if err := os.RemoveAll(filepath.Join(h.dir, ids[len(ids)-1])); err != nil {
+			return "", err
+		}
+		ids = ids[:len(ids)-1]
+	}
+	return id, nil
+}
+
+// ids returns the IDs of the stored runs, newest first.
+func (h *runHistory) ids() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if e.IsDir() && runIDPattern.MatchString(e.Name()) {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// list returns the summaries of the stored runs, newest first.
+func (h *runHistory) list() ([]RunSummary, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]RunSummary, 0, len(ids))
+	for _, id := range ids {
+		report, err := h.loadReport(id)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, RunSummary{
+			ID:         id,
+			StartTime:  report.StartTime.UTC().Format("2006-01-02 15:04:05 MST"),
+			Successful: report.Successful,
+			Error:      report.Error,
+			Triggers:   report.Triggers,
+		})
+	}
+	return summaries, nil
+}
+
+// report returns the report of the given run.
+func (h *runHistory) report(id string) (*RunReport, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.loadReport(id)
+}
+
+func (h *runHistory) loadReport(id string) (*RunReport, error) {
+	if !runIDPattern.MatchString(id) {
+		return nil, errRunNotFound
+	}
+	report, err := loadRunReport(filepath.Join(h.dir, id, runReportName))
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, errRunNotFound
+	}
+	return report, nil
+}
+
+// logs returns the logs of the given run.
+func (h *runHistory) logs(id string) ([]byte, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if !runIDPattern.MatchString(id) {
+		return nil, errRunNotFound
+	}
+	bs, err := os.ReadFile(filepath.Join(h.dir, id, runLogName))
+	if os.IsNotExist(err) {
+		return nil, errRunNotFound
+	}
+	return bs, err
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHistory(t *testing.T) {
+	h := newRunHistory(t.TempDir(), 2)
+	start := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	for i, successful := range []bool{true, false, true} {
+		report := &RunReport{StartTime: start.Add(time.Duration(i) * time.Minute), Successful: successful}
+		if !successful {
+			report.Error = "exit status 1"
+			report.Repositories = []*RepositoryReport{{Name: "api", Branches: []*BranchReport{{Name: "master", Error: "<conflict>"}}}}
+		}
+		if _, err := h.add(report, "log of run "+report.StartTime.Format("15:04")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// same start time
+	if id, err := h.add(&RunReport{StartTime: start.Add(2 * time.Minute), Successful: true}, ""); err != nil || id != "20260304-050807-1" {
+		t.Fatalf("expected a unique ID, got %q, %v", id, err)
+	}
+
+	runs, err := h.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	if strings.Join(ids, ",") != "20260304-050807-1,20260304-050807" {
+		t.Fatalf("expected the two newest runs, got %v", ids)
+	}
+
+	s := httptest.NewServer((&Server{History: h}).handler())
+	defer s.Close()
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(s.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		bs, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(bs)
+	}
+
+	// the failed run dropped out, add another one
+	if _, err := h.add(&RunReport{StartTime: start.Add(3 * time.Minute), Error: "exit status 1", Repositories: []*RepositoryReport{
+		{Name: "api", Branches: []*BranchReport{{Name: "master", Error: "<conflict>"}}},
+	}}, "failed log"); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, body := get("/runs"); code != http.StatusOK || !strings.Contains(body, `<a href="/runs/20260304-050907">`) || strings.Contains(body, "20260304-050807\"") {
+		t.Errorf("unexpected /runs: %d\n%s", code, body)
+	}
+	code, body := get("/runs?format=json")
+	var summaries []RunSummary
+	if err := json.Unmarshal([]byte(body), &summaries); err != nil || code != http.StatusOK || len(summaries) != 2 || summaries[0].Successful {
+		t.Errorf("unexpected /runs?format=json: %d, %v\n%s", code, err, body)
+	}
+	if code, body := get("/runs/20260304-050907"); code != http.StatusOK || !strings.Contains(body, "Failed: exit status 1") || !strings.Contains(body, "&lt;conflict&gt;") {
+		t.Errorf("unexpected run page: %d\n%s", code, body)
+	}
+	code, body = get("/runs/20260304-050907?format=json")
+	var report RunReport
+	if err := json.Unmarshal([]byte(body), &report); err != nil || code != http.StatusOK || report.Error != "exit status 1" {
+		t.Errorf("unexpected run JSON: %d, %v\n%s", code, err, body)
+	}
+	if code, body := get("/runs/20260304-050907/log"); code != http.StatusOK || body != "failed log" {
+		t.Errorf("unexpected run log: %d\n%s", code, body)
+	}
+	for _, path := range []string{"/runs/20260304-050607", "/runs/20260304-050607/log", "/runs/..%2F..%2Fetc/log"} {
+		if code, _ := get(path); code != http.StatusNotFound {
+			t.Errorf("expected %s to be not found, got %d", path, code)
+		}
+	}
+
+	disabled := httptest.NewServer((&Server{}).handler())
+	defer disabled.Close()
+	resp, err := http.Get(disabled.URL + "/runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected disabled history to be not found, got %d", resp.StatusCode)
+	}
+}
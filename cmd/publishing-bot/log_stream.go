@@ -0,0 +1,151 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"sync"
+)
+
+// logSubscriberBuffer is the number of writes buffered for a viewer. Slower
+// viewers are disconnected, such that they never block the run.
+const logSubscriberBuffer = 1024
+
+// logBacklogSize is the number of bytes of the most recent logs sent to
+// viewers joining late. The complete logs are in the run history.
+const logBacklogSize = 1 << 20
+
+// logBacklogTruncated precedes the backlog if older logs were dropped.
+const logBacklogTruncated = "[earlier logs truncated]\n"
+
+// logBroadcaster is a writer copying the logs of the current run to all
+// subscribed viewers. It keeps the most recent logs of the run for viewers
+// joining late. It is safe for concurrent use, and all methods are nil-safe.
+type logBroadcaster struct {
+	lock        sync.Mutex
+	running     bool
+	backlog     []byte
+	truncated   bool
+	subscribers map[chan []byte]struct{}
+}
+
+func newLogBroadcaster() *logBroadcaster {
+	return &logBroadcaster{subscribers: map[chan []byte]struct{}{}}
+}
+
+// start begins a new run, dropping the logs of the last one.
+func (b *logBroadcaster) start() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.running = true
+	b.backlog = nil
+	b.truncated = false
+}
+
+// finish ends the run and disconnects all viewers.
+func (b *logBroadcaster) finish() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.running = false
+	b.backlog = nil
+	b.truncated = false
+	for ch := range b.subscribers {
+		close(ch)
+		delete(b.subscribers, ch)
+	}
+}
+
+func (b *logBroadcaster) Write(p []byte) (int, error) {
+	if b == nil {
+		return len(p), nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return len(p), nil
+	}
+	b.backlog = append(b.backlog, p...)
+	// trim only after twice the size, such that not every write copies
+	if len(b.backlog) > 2*logBacklogSize {
+		b.backlog = trimBacklog(b.backlog, logBacklogSize)
+		b.truncated = true
+	}
+	c := append([]byte(nil), p...)
+	for ch := range b.subscribers {
+		select {
+		case ch <- c:
+		default:
+			close(ch)
+			delete(b.subscribers, ch)
+		}
+	}
+	return len(p), nil
+}
+
+// subscribe returns the most recent logs of the current run, and a channel
+// with the following writes. The channel is closed when the run finishes. It
+// returns a nil channel if no run is in progress. cancel must be called when
+// the viewer disconnects.
+func (b *logBroadcaster) subscribe() (backlog []byte, ch <-chan []byte, cancel func()) {
+	if b == nil {
+		return nil, nil, func() {}
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.running {
+		return nil, nil, func() {}
+	}
+	c := make(chan []byte, logSubscriberBuffer)
+	b.subscribers[c] = struct{}{}
+	backlog = b.backlog
+	if len(backlog) > logBacklogSize {
+		backlog = trimBacklog(backlog, logBacklogSize)
+	} else {
+		backlog = append([]byte(nil), backlog...)
+	}
+	if b.truncated || len(backlog) < len(b.backlog) {
+		backlog = append([]byte(logBacklogTruncated), backlog...)
+	}
+	return backlog, c, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		if _, ok := b.subscribers[c]; ok {
+			close(c)
+			delete(b.subscribers, c)
+		}
+	}
+}
+
+// trimBacklog returns a copy of the last size bytes of the backlog, starting
+// at a line.
+func trimBacklog(backlog []byte, size int) []byte {
+	tail := backlog[len(backlog)-size:]
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+	return append([]byte(nil), tail...)
+}
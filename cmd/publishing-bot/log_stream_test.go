@@ -0,0 +1,131 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCurrentLogStreaming(t *testing.T) {
+	b := newLogBroadcaster()
+	s := httptest.NewServer((&Server{LogStream: b}).handler())
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + "/runs/current/log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected no run in progress, got %d", resp.StatusCode)
+	}
+
+	b.start()
+	l, err := newPublisherLog(&bytes.Buffer{}, b, filepath.Join(t.TempDir(), "run.log"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Infof("first")
+
+	// two viewers joining at different times
+	var viewers []*bufio.Reader
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(s.URL + "/runs/current/log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected run in progress, got %d", resp.StatusCode)
+		}
+		r := bufio.NewReader(resp.Body)
+		if line, err := r.ReadString('\n'); err != nil || !strings.HasSuffix(line, "]: first\n") {
+			t.Fatalf("expected backlog, got %q, %v", line, err)
+		}
+		viewers = append(viewers, r)
+	}
+
+	l.Infof("second")
+	for _, r := range viewers {
+		if line, err := r.ReadString('\n'); err != nil || !strings.HasSuffix(line, "]: second\n") {
+			t.Errorf("expected live line, got %q, %v", line, err)
+		}
+	}
+
+	b.finish()
+	for _, r := range viewers {
+		if rest, err := io.ReadAll(r); err != nil || len(rest) != 0 {
+			t.Errorf("expected the stream to end with the run, got %q, %v", rest, err)
+		}
+	}
+}
+
+func TestLogBroadcasterDropsSlowViewers(t *testing.T) {
+	b := newLogBroadcaster()
+	b.start()
+	_, ch, cancel := b.subscribe()
+	defer cancel()
+
+	for i := 0; i <= logSubscriberBuffer; i++ {
+		if _, err := b.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != logSubscriberBuffer {
+		t.Errorf("expected %d buffered writes before the viewer was dropped, got %d", logSubscriberBuffer, n)
+	}
+}
+
+func TestLogBroadcasterCapsBacklog(t *testing.T) {
+	b := newLogBroadcaster()
+	b.start()
+	defer b.finish()
+
+	line := []byte(strings.Repeat("x", 99) + "\n")
+	for i := 0; i < 3*logBacklogSize/len(line); i++ {
+		if _, err := b.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(b.backlog) > 2*logBacklogSize {
+		t.Errorf("expected the backlog to be capped, got %d bytes", len(b.backlog))
+	}
+
+	backlog, _, cancel := b.subscribe()
+	defer cancel()
+	rest, ok := bytes.CutPrefix(backlog, []byte(logBacklogTruncated))
+	if !ok {
+		t.Fatalf("expected truncation marker, got %q", backlog[:len(line)])
+	}
+	if len(rest) > logBacklogSize || len(rest) < logBacklogSize-len(line) {
+		t.Errorf("expected about %d bytes of backlog, got %d", logBacklogSize, len(rest))
+	}
+	if !bytes.HasPrefix(rest, line) || !bytes.HasSuffix(rest, line) {
+		t.Errorf("expected the backlog to consist of complete lines")
+	}
+}
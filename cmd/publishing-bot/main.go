@@ -28,13 +28,17 @@ import (
 
 	"github.com/go-git/go-git/v5/storage"
 	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/yaml.v2"
 	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/notify"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
 )
 
 func Usage() {
 	fmt.Fprintf(os.Stderr, `
 Usage: %s [-config <config-yaml-file>] [-dry-run] [-token-file <token-file>] [-interval <sec>]
+          [-github-app-id <id> -github-app-installation-id <id> -github-app-private-key-file <file>]
           [-source-repo <repo>] [-target-org <org>]
 
 Command line flags override config values.
@@ -46,10 +50,15 @@ Command line flags override config values.
 func main() {
 	configFilePath := flag.String("config", "", "the config file in yaml format")
 	githubHost := flag.String("github-host", "", "the address of github (defaults to github.com)")
+	gitProvider := flag.String("provider", "", "the kind of git hosting at github-host: github, gitlab, gitea, forgejo or git (defaults to github)")
 	basePackage := flag.String("base-package", "", "the name of the package base (defaults to k8s.io when source repo is kubernetes, "+
 		"otherwise github-host/target-org)")
 	dryRun := flag.Bool("dry-run", false, "do not push anything to github")
 	tokenFile := flag.String("token-file", "", "the file with the github token")
+	githubAppID := flag.Int64("github-app-id", 0, "the GitHub App to authenticate as instead of with a token file")
+	githubAppInstallationID := flag.Int64("github-app-installation-id", 0, "the installation of the GitHub App in the target org")
+	githubAppPrivateKeyFile := flag.String("github-app-private-key-file", "", "the file with the PEM encoded private key of the GitHub App")
+	webhookSecretFile := flag.String("webhook-secret-file", "", "the file with the secret of the github push webhook of the source repo")
 	rulesFile := flag.String("rules-file", "", "the file or URL with repository rules")
 	// TODO: make absolute
 	repoName := flag.String("source-repo", "", "the name of the source repository (eg. kubernetes)")
@@ -58,6 +67,13 @@ func main() {
 	basePublishScriptPath := flag.String("base-publish-script-path", "./publish_scripts", `the base path in source repo where bot will look for publishing scripts`)
 	interval := flag.Uint("interval", 0, "loop with the given seconds of wait in between")
 	serverPort := flag.Int("server-port", 0, "start a webserver on the given port listening on 0.0.0.0")
+	isolateFailures := flag.Bool("isolate-failures", false, "continue with independent repositories if a repository fails")
+	rewriteTags := flag.Bool("rewrite-tags", false, "delete or recreate destination tags whose source tag was deleted or moved, if reconcile-tags is enabled in the rules")
+	repositoryIssues := flag.Bool("repository-issues", false, "report failures on a tracking issue in each failed destination repository")
+	issueLabel := flag.String("issue-label", "", "the label of the per-repository tracking issues (defaults to publishing-bot)")
+	runHistory := flag.Int("run-history", 0, "the number of runs kept with their logs and reports for the /runs endpoint (defaults to 20)")
+	logFormat := flag.String("log-format", "", "the format of run.log and of command output on stdout: text or json (defaults to text)")
+	constructWorkers := flag.Int("construct-workers", 0, "the number of independent repositories to construct concurrently (defaults to 1)")
 
 	flag.Usage = Usage
 	flag.Parse()
@@ -89,6 +105,18 @@ func main() {
 	if *tokenFile != "" {
 		cfg.TokenFile = *tokenFile
 	}
+	if *githubAppID != 0 {
+		cfg.GithubAppID = *githubAppID
+	}
+	if *githubAppInstallationID != 0 {
+		cfg.GithubAppInstallationID = *githubAppInstallationID
+	}
+	if *githubAppPrivateKeyFile != "" {
+		cfg.GithubAppPrivateKeyFile = *githubAppPrivateKeyFile
+	}
+	if *webhookSecretFile != "" {
+		cfg.WebhookSecretFile = *webhookSecretFile
+	}
 	if *rulesFile != "" {
 		cfg.RulesFile = *rulesFile
 	}
@@ -98,9 +126,33 @@ func main() {
 	if *githubHost != "" {
 		cfg.GithubHost = *githubHost
 	}
+	if *gitProvider != "" {
+		cfg.Provider = *gitProvider
+	}
 	if *basePackage != "" {
 		cfg.BasePackage = *basePackage
 	}
+	if *isolateFailures {
+		cfg.IsolateFailures = true
+	}
+	if *rewriteTags {
+		cfg.RewriteTags = true
+	}
+	if *repositoryIssues {
+		cfg.RepositoryIssues = true
+	}
+	if *issueLabel != "" {
+		cfg.IssueLabel = *issueLabel
+	}
+	if *runHistory != 0 {
+		cfg.RunHistory = *runHistory
+	}
+	if *logFormat != "" {
+		cfg.LogFormat = *logFormat
+	}
+	if *constructWorkers != 0 {
+		cfg.ConstructWorkers = *constructWorkers
+	}
 
 	// defaulting to github.com when it is not specified.
 	if cfg.GithubHost == "" {
@@ -111,6 +163,20 @@ func main() {
 		cfg.GitDefaultBranch = "master"
 	}
 
+	if cfg.ConstructWorkers < 1 {
+		cfg.ConstructWorkers = 1
+	}
+
+	if cfg.RunHistory < 1 {
+		cfg.RunHistory = defaultRunHistory
+	}
+
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = LogFormatText
+	} else if cfg.LogFormat != LogFormatText && cfg.LogFormat != LogFormatJSON {
+		glog.Fatalf("Unknown log-format %q, must be %s or %s", cfg.LogFormat, LogFormatText, LogFormatJSON)
+	}
+
 	var err error
 	cfg.BasePublishScriptPath, err = filepath.Abs(cfg.BasePublishScriptPath)
 	if err != nil {
@@ -148,11 +214,42 @@ func main() {
 
 	runChan := make(chan bool, 1)
 
+	tokenSource, err := provider.TokenSource(&cfg)
+	if err != nil {
+		glog.Fatalf("Failed to set up authentication: %v", err)
+	}
+	prov, err := provider.New(&cfg, tokenSource)
+	if err != nil {
+		glog.Fatalf("Failed to set up git provider: %v", err)
+	}
+
 	// start server
 	server := Server{
-		Issue:   cfg.GithubIssue,
-		config:  cfg,
-		RunChan: runChan,
+		Issue:    cfg.GithubIssue,
+		Provider: prov,
+		config:   cfg,
+		RunChan:  runChan,
+	}
+	if cfg.WebhookSecretFile != "" {
+		bs, err := os.ReadFile(cfg.WebhookSecretFile)
+		if err != nil {
+			glog.Fatalf("Failed to load webhook secret file from %q: %v", cfg.WebhookSecretFile, err)
+		}
+		server.WebhookSecret = []byte(strings.TrimSpace(string(bs)))
+	}
+	history := newRunHistory(filepath.Join(baseRepoPath, runsDirName), cfg.RunHistory)
+	server.History = history
+	logStream := newLogBroadcaster()
+	server.LogStream = logStream
+	lastReport, err := loadRunReport(filepath.Join(baseRepoPath, runReportFileName))
+	if err != nil {
+		glog.Warningf("Failed to load last run report: %v", err)
+	} else if lastReport != nil {
+		server.SetReport(lastReport)
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		glog.Fatalf("Failed to set up notifications: %v", err)
 	}
 	if *serverPort != 0 {
 		server.Run(*serverPort)
@@ -163,29 +260,44 @@ func main() {
 		githubIssueErrorf = glog.Errorf
 	}
 
+	metrics := newPublisherMetrics(prometheus.DefaultRegisterer)
+
 	var publisherErr error
 
 	for {
 		waitfor := *interval
 		last := time.Now()
-		publisher := New(&cfg, baseRepoPath)
+		publisher := New(&cfg, baseRepoPath, prov, tokenSource, metrics)
+		publisher.SetTriggers(server.TakeTriggers())
+		publisher.SetLogStream(logStream)
 
-		if cfg.TokenFile != "" && cfg.GithubIssue != 0 && !cfg.DryRun {
+		var logs string
+		if tokenSource != nil && (cfg.GithubIssue != 0 || cfg.RepositoryIssues) && !cfg.DryRun {
 			// load token
-			bs, err := os.ReadFile(cfg.TokenFile)
+			tok, err := tokenSource.Token()
 			if err != nil {
-				glog.Fatalf("Failed to load token file from %q: %v", cfg.TokenFile, err)
+				glog.Fatalf("Failed to load token: %v", err)
 			}
-			token := strings.Trim(string(bs), " \t\n")
+			token := tok.AccessToken
 
 			// run
-			logs, hash, err := publisher.Run()
+			var hash string
+			logs, hash, err = publisher.Run()
 			server.SetHealth(err == nil, hash)
+			server.SetReport(publisher.Report())
+			if cfg.RepositoryIssues {
+				if err := ReportOnRepositoryIssues(prov, &cfg, publisher.Report(), logs, token); err != nil {
+					githubIssueErrorf("Failed to update repository issues: %v", err)
+					server.SetHealth(false, hash)
+				}
+			}
 			if err != nil {
 				glog.Infof("Failed to run publisher: %v", err)
-				if err := ReportOnIssue(err, logs, token, cfg.TargetOrg, cfg.SourceRepo, cfg.GithubIssue); err != nil {
-					githubIssueErrorf("Failed to report logs on github issue: %v", err)
-					server.SetHealth(false, hash)
+				if cfg.GithubIssue != 0 {
+					if err := ReportOnIssue(prov, publisher.Report(), logs, token, cfg.TargetOrg, cfg.SourceRepo, cfg.GithubIssue); err != nil {
+						githubIssueErrorf("Failed to report logs on github issue: %v", err)
+						server.SetHealth(false, hash)
+					}
 				}
 				if strings.HasSuffix(err.Error(), storage.ErrReferenceHasChanged.Error()) {
 					// TODO: If the issue is just "reference has changed concurrently",
@@ -194,16 +306,34 @@ func main() {
 					glog.Infof("Waiting for 5 minutes")
 					waitfor = uint(5 * 60)
 				}
-			} else if err := CloseIssue(token, cfg.TargetOrg, cfg.SourceRepo, cfg.GithubIssue); err != nil {
-				githubIssueErrorf("Failed to close issue: %v", err)
-				server.SetHealth(false, hash)
+			} else if cfg.GithubIssue != 0 {
+				if err := CloseIssue(prov, cfg.TargetOrg, cfg.SourceRepo, cfg.GithubIssue); err != nil {
+					githubIssueErrorf("Failed to close issue: %v", err)
+					server.SetHealth(false, hash)
+				}
 			}
 		} else {
 			// run
-			if _, _, publisherErr = publisher.Run(); publisherErr != nil {
+			if logs, _, publisherErr = publisher.Run(); publisherErr != nil {
 				glog.Infof("Failed to run publisher: %v", publisherErr)
 			}
+			server.SetReport(publisher.Report())
+		}
+
+		if branches := publisher.SourceBranches(); branches != nil {
+			server.SetSourceBranches(branches)
+		}
+
+		report := publisher.Report()
+		if _, err := history.add(report, logs); err != nil {
+			glog.Errorf("Failed to store run in history: %v", err)
+		}
+		if notifier != nil && !cfg.DryRun {
+			if err := notifyRun(notifier, runEvents(&cfg, prov, lastReport, report)); err != nil {
+				glog.Errorf("Failed to send notifications: %v", err)
+			}
 		}
+		lastReport = report
 
 		if *interval == 0 {
 			// This condition is specifically used by the CI to get the exit code
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "publishing_bot"
+
+// publisherMetrics are the Prometheus metrics fed by the PublisherMunger. A nil
+// *publisherMetrics records nothing.
+type publisherMetrics struct {
+	runDuration       prometheus.Histogram
+	runs              *prometheus.CounterVec
+	constructDuration *prometheus.HistogramVec
+	pushDuration      *prometheus.HistogramVec
+	commitsPublished  *prometheus.CounterVec
+	tagsCreated       *prometheus.CounterVec
+	lastSuccess       prometheus.Gauge
+
+	lock            sync.Mutex
+	lastSuccessTime time.Time
+}
+
+func newPublisherMetrics(reg prometheus.Registerer) *publisherMetrics {
+	// constructing a branch takes from seconds to hours
+	buckets := prometheus.ExponentialBuckets(1, 4, 8)
+
+	m := &publisherMetrics{
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_duration_seconds",
+			Help:      "Duration of publishing runs.",
+			Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+		}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "runs_total",
+			Help:      "Number of publishing runs by result (success or failure).",
+		}, []string{"result"}),
+		constructDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "construct_duration_seconds",
+			Help:      "Duration of the construction of a branch of a destination repository.",
+			Buckets:   buckets,
+		}, []string{"repository"}),
+		pushDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "push_duration_seconds",
+			Help:      "Duration of the push of a branch of a destination repository.",
+			Buckets:   buckets,
+		}, []string{"repository"}),
+		commitsPublished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "commits_published_total",
+			Help:      "Number of commits published to a branch of a destination repository.",
+		}, []string{"repository", "branch"}),
+		tagsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tags_created_total",
+			Help:      "Number of tags published to a destination repository.",
+		}, []string{"repository"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_successful_run_timestamp_seconds",
+			Help:      "Unix time of the end of the last successful run.",
+		}),
+	}
+	sinceLastSuccess := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "seconds_since_last_successful_run",
+		Help:      "Seconds since the end of the last successful run, or since the start of the bot if there was none.",
+	}, m.sinceLastSuccess)
+
+	m.lastSuccessTime = time.Now()
+	reg.MustRegister(m.runDuration, m.runs, m.constructDuration, m.pushDuration, m.commitsPublished, m.tagsCreated, m.lastSuccess, sinceLastSuccess)
+	return m
+}
+
+func (m *publisherMetrics) sinceLastSuccess() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return time.Since(m.lastSuccessTime).Seconds()
+}
+
+func (m *publisherMetrics) observeRun(d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.runDuration.Observe(d.Seconds())
+	if err != nil {
+		m.runs.WithLabelValues("failure").Inc()
+		return
+	}
+	m.runs.WithLabelValues("success").Inc()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lastSuccessTime = time.Now()
+	m.lastSuccess.Set(float64(m.lastSuccessTime.Unix()))
+}
+
+func (m *publisherMetrics) observeConstruct(repo string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.constructDuration.WithLabelValues(repo).Observe(d.Seconds())
+}
+
+func (m *publisherMetrics) observePush(repo, branch string, d time.Duration, commits, tags int) {
+	if m == nil {
+		return
+	}
+	m.pushDuration.WithLabelValues(repo).Observe(d.Seconds())
+	m.commitsPublished.WithLabelValues(repo, branch).Add(float64(commits))
+	m.tagsCreated.WithLabelValues(repo).Add(float64(tags))
+}
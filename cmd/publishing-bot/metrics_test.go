@@ -0,0 +1,60 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPublisherMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newPublisherMetrics(reg)
+
+	m.observeConstruct("api", time.Second)
+	m.observePush("api", "master", time.Second, 3, 1)
+	m.observePush("api", "master", time.Second, 2, 0)
+	m.observeRun(time.Minute, errors.New("boom"))
+	m.observeRun(time.Minute, nil)
+
+	if got := testutil.ToFloat64(m.commitsPublished.WithLabelValues("api", "master")); got != 5 {
+		t.Errorf("expected 5 commits published, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.tagsCreated.WithLabelValues("api")); got != 1 {
+		t.Errorf("expected 1 tag created, got %v", got)
+	}
+	for _, result := range []string{"success", "failure"} {
+		if got := testutil.ToFloat64(m.runs.WithLabelValues(result)); got != 1 {
+			t.Errorf("expected 1 run with result %s, got %v", result, got)
+		}
+	}
+	if got := testutil.ToFloat64(m.lastSuccess); got == 0 {
+		t.Errorf("expected the last successful run to be set")
+	}
+	// runs_total has a series per result
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 9 {
+		t.Errorf("expected 9 metric series, got %d, %v", n, err)
+	}
+
+	// nil metrics record nothing
+	var nilMetrics *publisherMetrics
+	nilMetrics.observeRun(time.Minute, nil)
+}
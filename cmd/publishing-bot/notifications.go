@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/notify"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
+)
+
+// runEvents returns the events of a finished run. previous is the report of
+// the run before, or nil. Failures are only reported when a run fails after a
+// successful one, not again on every run while the failure persists.
+func runEvents(cfg *config.Config, prov provider.Provider, previous, report *RunReport) []*notify.Event {
+	source := cfg.SourceOrg + "/" + cfg.SourceRepo
+	now := time.Now()
+	if report.EndTime != nil {
+		now = *report.EndTime
+	}
+
+	var events []*notify.Event
+	switch {
+	case !report.Successful && (previous == nil || previous.Successful):
+		e := &notify.Event{
+			Kind:    notify.EventFailure,
+			Source:  source,
+			Summary: fmt.Sprintf("Publishing from %s failed", source),
+			Details: report.Error,
+			Time:    now,
+		}
+		if len(report.Failures) > 0 {
+			e.Details = formatFailures(report.Failures)
+		}
+		if cfg.GithubIssue != 0 {
+			e.URL = prov.IssueURL(cfg.TargetOrg, cfg.SourceRepo, cfg.GithubIssue)
+		}
+		events = append(events, e)
+	case report.Successful && previous != nil && !previous.Successful:
+		events = append(events, &notify.Event{
+			Kind:    notify.EventRecovery,
+			Source:  source,
+			Summary: fmt.Sprintf("Publishing from %s recovered", source),
+			Time:    now,
+		})
+	}
+
+	tags := map[string][]string{}
+	count := 0
+	for _, rr := range report.Repositories {
+		for _, br := range rr.Branches {
+			if br.Push != ResultPushed || len(br.TagsCreated) == 0 {
+				continue
+			}
+			repo := cfg.TargetOrg + "/" + rr.Name
+			tags[repo] = append(tags[repo], br.TagsCreated...)
+			count += len(br.TagsCreated)
+		}
+	}
+	if count > 0 {
+		events = append(events, &notify.Event{
+			Kind:    notify.EventNewTag,
+			Source:  source,
+			Summary: fmt.Sprintf("Published %d new tags from %s", count, source),
+			Tags:    tags,
+			Time:    now,
+		})
+	}
+
+	return events
+}
+
+// notifyRun sends the events of a finished run.
+func notifyRun(notifier notify.Notifier, events []*notify.Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var errs []error
+	for _, e := range events {
+		if err := notifier.Notify(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify about %s: %w", e.Kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/notify"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
+)
+
+func TestRunEvents(t *testing.T) {
+	cfg := &config.Config{Provider: config.ProviderGit, SourceOrg: "kubernetes", SourceRepo: "kubernetes", TargetOrg: "k8s"}
+	prov, err := provider.New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := &RunReport{Error: "exit status 1"}
+	succeeded := &RunReport{
+		Successful: true,
+		Repositories: []*RepositoryReport{
+			{Name: "api", Branches: []*BranchReport{
+				{Name: "master", Push: ResultPushed, TagsCreated: []string{"v0.31.0"}},
+				{Name: "release-1.30", Push: ResultPushed, TagsCreated: []string{"v0.30.1"}},
+			}},
+			{Name: "client-go", Branches: []*BranchReport{{Name: "master", Push: ResultSkipped, TagsCreated: []string{"v0.31.0"}}}},
+		},
+	}
+
+	kinds := func(events []*notify.Event) []string {
+		var ks []string
+		for _, e := range events {
+			ks = append(ks, e.Kind)
+		}
+		return ks
+	}
+
+	events := runEvents(cfg, prov, succeeded, failed)
+	if want := []string{notify.EventFailure}; !reflect.DeepEqual(kinds(events), want) {
+		t.Fatalf("expected %v, got %v", want, kinds(events))
+	}
+	if events[0].Details != "exit status 1" {
+		t.Errorf("unexpected failure details: %q", events[0].Details)
+	}
+
+	if events := runEvents(cfg, prov, nil, failed); len(events) != 1 {
+		t.Errorf("expected one failure event after a restart, got %v", kinds(events))
+	}
+
+	if events := runEvents(cfg, prov, failed, failed); len(events) != 0 {
+		t.Errorf("expected no event for a persistent failure, got %v", kinds(events))
+	}
+
+	events = runEvents(cfg, prov, failed, succeeded)
+	if want := []string{notify.EventRecovery, notify.EventNewTag}; !reflect.DeepEqual(kinds(events), want) {
+		t.Fatalf("expected %v, got %v", want, kinds(events))
+	}
+	if want := map[string][]string{"k8s/api": {"v0.31.0", "v0.30.1"}}; !reflect.DeepEqual(events[1].Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, events[1].Tags)
+	}
+
+	if events := runEvents(cfg, prov, nil, succeeded); !reflect.DeepEqual(kinds(events), []string{notify.EventNewTag}) {
+		t.Errorf("expected only new-tag event without previous run, got %v", kinds(events))
+	}
+}
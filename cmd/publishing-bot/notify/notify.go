@@ -0,0 +1,185 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// Kinds of events.
+const (
+	// EventFailure is sent when a run fails.
+	EventFailure = "failure"
+	// EventRecovery is sent when a run succeeds after a failed one.
+	EventRecovery = "recovery"
+	// EventNewTag is sent when tags are pushed.
+	EventNewTag = "new-tag"
+)
+
+var allEvents = []string{EventFailure, EventRecovery, EventNewTag}
+
+// Event is something the bot notifies about.
+type Event struct {
+	Kind string `json:"kind"`
+	// Source is the source repository as org/repo.
+	Source string `json:"source"`
+	// Summary is a one-line description, used as email subject.
+	Summary string `json:"summary"`
+	// Details are further lines, e.g. the failed repositories.
+	Details string `json:"details,omitempty"`
+	// Tags are the pushed tags by destination repository as org/repo.
+	Tags map[string][]string `json:"tags,omitempty"`
+	// URL links to more information, e.g. the issue with the logs.
+	URL  string    `json:"url,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// Text renders the event as plain text.
+func (e *Event) Text() string {
+	var sb strings.Builder
+	sb.WriteString(e.Summary)
+	sb.WriteString("\n")
+	if e.Details != "" {
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimRight(e.Details, "\n"))
+		sb.WriteString("\n")
+	}
+	if len(e.Tags) > 0 {
+		repos := make([]string, 0, len(e.Tags))
+		for repo := range e.Tags {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+		sb.WriteString("\n")
+		for _, repo := range repos {
+			fmt.Fprintf(&sb, "%s: %s\n", repo, strings.Join(e.Tags[repo], ", "))
+		}
+	}
+	if e.URL != "" {
+		fmt.Fprintf(&sb, "\n%s\n", e.URL)
+	}
+	return sb.String()
+}
+
+// Notifier sends events somewhere.
+type Notifier interface {
+	Notify(ctx context.Context, e *Event) error
+}
+
+type route struct {
+	name   string
+	events map[string]bool
+	sink   Notifier
+}
+
+// router sends each event to the sinks routed for its kind.
+type router struct {
+	routes []route
+}
+
+// New returns a notifier sending to the configured sinks, or nil if none is
+// configured.
+func New(cfgs []config.Notification) (Notifier, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	r := &router{}
+	for i, cfg := range cfgs {
+		name := fmt.Sprintf("notification %d (%s)", i, cfg.Type)
+		sink, err := newSink(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		events := cfg.Events
+		if len(events) == 0 {
+			events = allEvents
+		}
+		rt := route{name: name, events: map[string]bool{}, sink: sink}
+		for _, e := range events {
+			switch e {
+			case EventFailure, EventRecovery, EventNewTag:
+				rt.events[e] = true
+			default:
+				return nil, fmt.Errorf("invalid %s: unknown event %q, must be one of %s", name, e, strings.Join(allEvents, ", "))
+			}
+		}
+		r.routes = append(r.routes, rt)
+	}
+	return r, nil
+}
+
+func newSink(cfg *config.Notification) (Notifier, error) {
+	switch cfg.Type {
+	case config.NotificationSlack, config.NotificationWebhook:
+		url, err := readSecret(cfg.URL, cfg.URLFile)
+		if err != nil {
+			return nil, err
+		}
+		if url == "" {
+			return nil, errors.New("url or url-file is required")
+		}
+		if cfg.Type == config.NotificationSlack {
+			return NewSlack(url), nil
+		}
+		return NewWebhook(url), nil
+	case config.NotificationEmail:
+		if cfg.SMTPServer == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, errors.New("smtp-server, from and to are required")
+		}
+		password, err := readSecret("", cfg.SMTPPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewEmail(cfg.SMTPServer, cfg.SMTPUsername, password, cfg.From, cfg.To), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q, must be one of slack, webhook or email", cfg.Type)
+	}
+}
+
+// readSecret returns value, or the trimmed content of fileName if set.
+func readSecret(value, fileName string) (string, error) {
+	if fileName == "" {
+		return value, nil
+	}
+	bs, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
+
+// Notify sends e to all sinks routed for its kind, and returns their errors.
+func (r *router) Notify(ctx context.Context, e *Event) error {
+	var errs []error
+	for _, rt := range r.routes {
+		if !rt.events[e.Kind] {
+			continue
+		}
+		if err := rt.sink.Notify(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rt.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
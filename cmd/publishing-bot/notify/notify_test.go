@@ -0,0 +1,256 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+var testEvent = &Event{
+	Kind:    EventNewTag,
+	Source:  "kubernetes/kubernetes",
+	Summary: "Published 2 new tags from kubernetes/kubernetes",
+	Tags:    map[string][]string{"k8s/api": {"v0.30.0", "v0.30.1"}},
+	Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+// recorder is an HTTP stub recording the posted bodies.
+type recorder struct {
+	lock   sync.Mutex
+	bodies []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	//nolint:errcheck // test server
+	body, _ := io.ReadAll(req.Body)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.bodies = append(r.bodies, string(body))
+}
+
+func newRecorder(t *testing.T) (*recorder, string) {
+	t.Helper()
+	r := &recorder{}
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+	return r, s.URL
+}
+
+func TestSlack(t *testing.T) {
+	r, url := newRecorder(t)
+	if err := NewSlack(url).Notify(context.Background(), testEvent); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.bodies) != 1 {
+		t.Fatalf("expected one post, got %q", r.bodies)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(r.bodies[0]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := "Published 2 new tags from kubernetes/kubernetes\n\nk8s/api: v0.30.0, v0.30.1\n"
+	if payload["text"] != want {
+		t.Errorf("expected text %q, got %q", want, payload["text"])
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	r, url := newRecorder(t)
+	if err := NewWebhook(url).Notify(context.Background(), testEvent); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.bodies) != 1 {
+		t.Fatalf("expected one post, got %q", r.bodies)
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(r.bodies[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != EventNewTag || got.Source != testEvent.Source || len(got.Tags["k8s/api"]) != 2 || !got.Time.Equal(testEvent.Time) {
+		t.Errorf("unexpected event: %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer failing.Close()
+	if err := NewWebhook(failing.URL+"/secret").Notify(context.Background(), testEvent); err == nil {
+		t.Errorf("expected error")
+	} else if strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected error without URL path, got: %v", err)
+	}
+}
+
+// smtpStub is a minimal SMTP server accepting one mail per connection.
+type smtpStub struct {
+	addr string
+
+	lock  sync.Mutex
+	rcpts []string
+	data  string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &smtpStub{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		//nolint:errcheck // test server
+		io.WriteString(conn, line+"\r\n")
+	}
+	reply("220 localhost ESMTP stub")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.lock.Lock()
+			s.rcpts = append(s.rcpts, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			s.lock.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.lock.Lock()
+			s.data = data.String()
+			s.lock.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	s := newSMTPStub(t)
+	e := NewEmail(s.addr, "", "", "bot@example.com", []string{"a@example.com", "b@example.com"})
+	if err := e.Notify(context.Background(), testEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.rcpts) != 2 || s.rcpts[0] != "a@example.com" || s.rcpts[1] != "b@example.com" {
+		t.Errorf("unexpected recipients: %q", s.rcpts)
+	}
+	for _, want := range []string{
+		"Subject: Published 2 new tags from kubernetes/kubernetes\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"\r\n\r\nPublished 2 new tags from kubernetes/kubernetes\r\n\r\nk8s/api: v0.30.0, v0.30.1\r\n",
+	} {
+		if !strings.Contains(s.data, want) {
+			t.Errorf("expected mail to contain %q, got:\n%s", want, s.data)
+		}
+	}
+}
+
+func TestNewRoutesEvents(t *testing.T) {
+	failures, failuresURL := newRecorder(t)
+	all, allURL := newRecorder(t)
+	urlFile := filepath.Join(t.TempDir(), "url")
+	if err := os.WriteFile(urlFile, []byte(allURL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := New([]config.Notification{
+		{Type: config.NotificationWebhook, URL: failuresURL, Events: []string{EventFailure}},
+		{Type: config.NotificationSlack, URLFile: urlFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{EventFailure, EventRecovery, EventNewTag} {
+		if err := n.Notify(context.Background(), &Event{Kind: kind, Summary: kind}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(failures.bodies) != 1 || !strings.Contains(failures.bodies[0], `"kind":"failure"`) {
+		t.Errorf("expected only the failure on the first sink, got %q", failures.bodies)
+	}
+	if len(all.bodies) != 3 {
+		t.Errorf("expected all events on the second sink, got %q", all.bodies)
+	}
+
+	for _, cfgs := range [][]config.Notification{
+		{{Type: "pager"}},
+		{{Type: config.NotificationSlack}},
+		{{Type: config.NotificationEmail, SMTPServer: "localhost:25"}},
+		{{Type: config.NotificationWebhook, URL: failuresURL, Events: []string{"success"}}},
+	} {
+		if _, err := New(cfgs); err == nil {
+			t.Errorf("expected error for %+v", cfgs)
+		}
+	}
+	if n, err := New(nil); n != nil || err != nil {
+		t.Errorf("expected no notifier, got %v, %v", n, err)
+	}
+}
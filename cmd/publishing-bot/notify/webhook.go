@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON posts v as JSON to url.
+func postJSON(ctx context.Context, target string, v interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// the URL might contain a secret, e.g. for Slack
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		//nolint:errcheck // best effort for the error message
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to post to %s: HTTP code %d: %s", req.URL.Host, resp.StatusCode, body)
+	}
+	return nil
+}
+
+type slack struct {
+	url string
+}
+
+// NewSlack returns a notifier posting to a Slack incoming webhook.
+func NewSlack(url string) Notifier {
+	return &slack{url: url}
+}
+
+func (s *slack) Notify(ctx context.Context, e *Event) error {
+	return postJSON(ctx, s.url, map[string]string{"text": e.Text()})
+}
+
+type webhook struct {
+	url string
+}
+
+// NewWebhook returns a notifier posting the event as JSON to url.
+func NewWebhook(url string) Notifier {
+	return &webhook{url: url}
+}
+
+func (w *webhook) Notify(ctx context.Context, e *Event) error {
+	return postJSON(ctx, w.url, e)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	planJSONFileName = "dry-run-plan.json"
+	planTextFileName = "dry-run-plan.txt"
+
+	// emptyTreeHash is the hash of the empty git tree, to diff new branches against.
+	emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+)
+
+// Plan describes what a dry run would have pushed.
+type Plan struct {
+	Repositories []*RepositoryPlan `json:"repositories,omitempty"`
+}
+
+// RepositoryPlan describes what would be pushed to one destination repository.
+type RepositoryPlan struct {
+	Name     string        `json:"name"`
+	Branches []*BranchPlan `json:"branches,omitempty"`
+}
+
+// BranchPlan describes what would be pushed to one destination branch.
+type BranchPlan struct {
+	Name         string `json:"name"`
+	SourceBranch string `json:"sourceBranch"`
+	// RemoteHead is the current head of the remote branch, empty if it does
+	// not exist yet.
+	RemoteHead string          `json:"remoteHead,omitempty"`
+	NewHead    string          `json:"newHead"`
+	Commits    []PlannedCommit `json:"commits,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	// GoModDiff is the unified diff of go.mod between remote and new head.
+	GoModDiff string `json:"goModDiff,omitempty"`
+}
+
+// PlannedCommit is a commit that would be pushed.
+type PlannedCommit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	// SourceCommit is the commit in the source repository this commit was
+	// created from.
+	SourceCommit string `json:"sourceCommit,omitempty"`
+}
+
+// plan computes what publish would push. It is called instead of publish in
+// dry-run mode.
+func (p *PublisherMunger) plan(newUpstreamHeads map[string]plumbing.Hash) (*Plan, error) {
+	plan := &Plan{}
+	for _, repoRules := range p.reposRules.Rules {
+		if repoRules.Skip || p.failures.isFailed(repoRules.DestinationRepository) {
+			continue
+		}
+		dstDir := filepath.Join(p.baseRepoPath, repoRules.DestinationRepository)
+		rp := &RepositoryPlan{Name: repoRules.DestinationRepository}
+		for _, branchRule := range repoRules.Branches {
+			if p.skippedBranch(branchRule.Source.Branch) {
+				continue
+			}
+			if _, ok := newUpstreamHeads[branchRule.Source.Branch]; !ok {
+				return nil, fmt.Errorf("no upstream branch %q found", branchRule.Source.Branch)
+			}
+			bp, err := p.planBranch(dstDir, repoRules.DestinationRepository, branchRule.Name)
+			if err != nil {
+				return nil, err
+			}
+			bp.SourceBranch = branchRule.Source.Branch
+			rp.Branches = append(rp.Branches, bp)
+		}
+		plan.Repositories = append(plan.Repositories, rp)
+	}
+	return plan, nil
+}
+
+func (p *PublisherMunger) planBranch(dir, repo, branch string) (*BranchPlan, error) {
+	remoteHead, err := gitRevParse(dir, "refs/remotes/origin/"+branch)
+	if err != nil {
+		return nil, err
+	}
+	newHead, err := gitRevParse(dir, "refs/heads/"+branch)
+	if err != nil {
+		return nil, err
+	}
+	bp := &BranchPlan{Name: branch, RemoteHead: remoteHead, NewHead: newHead}
+
+	bp.Tags, err = pushScriptTags(filepath.Join(p.baseRepoPath, pushTagsFileName(repo, branch)))
+	if err != nil {
+		return nil, err
+	}
+	if newHead == "" || newHead == remoteHead {
+		return bp, nil
+	}
+
+	revRange, base := newHead, emptyTreeHash
+	if remoteHead != "" {
+		revRange, base = remoteHead+".."+newHead, remoteHead
+	}
+	log := exec.Command("git", "log", "-z", "--reverse",
+		fmt.Sprintf("--format=%%H%%x1f%%s%%x1f%%(trailers:key=%s,valueonly,separator=%%x2C)", p.commitMsgTag()),
+		revRange)
+	log.Dir = dir
+	out, err := log.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits %s in %s: %w", revRange, dir, err)
+	}
+	for _, record := range strings.Split(string(out), "\x00") {
+		if record = strings.TrimSpace(record); record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 3)
+		c := PlannedCommit{Hash: fields[0]}
+		if len(fields) > 1 {
+			c.Subject = fields[1]
+		}
+		if len(fields) > 2 {
+			c.SourceCommit = strings.TrimSpace(fields[2])
+		}
+		bp.Commits = append(bp.Commits, c)
+	}
+
+	diff := exec.Command("git", "diff", base, newHead, "--", "go.mod")
+	diff.Dir = dir
+	out, err = diff.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff go.mod in %s: %w", dir, err)
+	}
+	bp.GoModDiff = string(out)
+
+	return bp, nil
+}
+
+// String renders the plan for humans.
+func (plan *Plan) String() string {
+	var sb strings.Builder
+	for _, rp := range plan.Repositories {
+		fmt.Fprintf(&sb, "%s\n", rp.Name)
+		for _, bp := range rp.Branches {
+			if bp.RemoteHead == bp.NewHead && len(bp.Tags) == 0 {
+				fmt.Fprintf(&sb, "  %s (from %s): up to date\n", bp.Name, bp.SourceBranch)
+				continue
+			}
+			from := "new branch"
+			if bp.RemoteHead != "" {
+				from = shortHash(bp.RemoteHead)
+			}
+			fmt.Fprintf(&sb, "  %s (from %s): %s -> %s, %d commits\n", bp.Name, bp.SourceBranch, from, shortHash(bp.NewHead), len(bp.Commits))
+			for _, c := range bp.Commits {
+				fmt.Fprintf(&sb, "    + %s %s", shortHash(c.Hash), c.Subject)
+				if c.SourceCommit != "" {
+					fmt.Fprintf(&sb, " (source %s)", shortHash(c.SourceCommit))
+				}
+				sb.WriteString("\n")
+			}
+			for _, t := range bp.Tags {
+				fmt.Fprintf(&sb, "    tag %s\n", t)
+			}
+			if bp.GoModDiff != "" {
+				sb.WriteString("    go.mod:\n")
+				for _, l := range strings.Split(strings.TrimRight(bp.GoModDiff, "\n"), "\n") {
+					fmt.Fprintf(&sb, "      %s\n", l)
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+// writePlan writes the plan as JSON and text into dir.
+func writePlan(dir string, plan *Plan) error {
+	bs, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, planJSONFileName), bs, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, planTextFileName), []byte(plan.String()), 0o644)
+}
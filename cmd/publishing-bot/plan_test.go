@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestPlan(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "bot")
+	t.Setenv("GIT_AUTHOR_EMAIL", "bot@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "bot")
+	t.Setenv("GIT_COMMITTER_EMAIL", "bot@example.com")
+
+	baseRepoPath, remotes := t.TempDir(), t.TempDir()
+	remoteA, oldHead := setupPublishRepo(t, baseRepoPath, remotes, "a")
+	setupPublishRepo(t, baseRepoPath, remotes, "b")
+	gitCmd(t, filepath.Join(baseRepoPath, "b"), "reset", "-q", "--hard", "origin/master")
+
+	dirA := filepath.Join(baseRepoPath, "a")
+	if err := os.WriteFile(filepath.Join(dirA, "go.mod"), []byte("module example.com/a\n\nrequire example.com/b v0.1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dirA, "add", "go.mod")
+	gitCmd(t, dirA, "commit", "-q", "-m", "Bump b\n\nKubernetes-commit: 0123456789abcdef0123456789abcdef01234567")
+	newHead := gitCmd(t, dirA, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(baseRepoPath, pushTagsFileName("a", "master")), []byte(`#!/bin/bash
+git push --atomic origin refs/tags/v0.1.0
+`), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newPublishTestMunger(t, baseRepoPath, "a", "b")
+	p.config.DryRun = true
+	p.config.SourceRepo = "kubernetes"
+	if err := p.publish(map[string]plumbing.Hash{"master": plumbing.ZeroHash}); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, p.plog.Logs())
+	}
+	if got := gitCmd(t, remoteA, "rev-parse", "master"); got != oldHead {
+		t.Errorf("expected no push in dry-run mode, remote is at %s", got)
+	}
+
+	bs, err := os.ReadFile(filepath.Join(baseRepoPath, planJSONFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan Plan
+	if err := json.Unmarshal(bs, &plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Repositories) != 2 || len(plan.Repositories[0].Branches) != 1 {
+		t.Fatalf("unexpected plan: %s", bs)
+	}
+	a := plan.Repositories[0].Branches[0]
+	if a.RemoteHead != oldHead || a.NewHead != newHead {
+		t.Errorf("expected %s -> %s, got %s -> %s", oldHead, newHead, a.RemoteHead, a.NewHead)
+	}
+	if len(a.Commits) != 2 || a.Commits[0].Subject != "published" || a.Commits[1].Subject != "Bump b" {
+		t.Errorf("unexpected commits: %+v", a.Commits)
+	}
+	if len(a.Commits) == 2 && a.Commits[1].SourceCommit != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("unexpected source commit %q", a.Commits[1].SourceCommit)
+	}
+	if len(a.Tags) != 1 || a.Tags[0] != "v0.1.0" {
+		t.Errorf("unexpected tags: %v", a.Tags)
+	}
+	if !strings.Contains(a.GoModDiff, "+require example.com/b v0.1.0") {
+		t.Errorf("unexpected go.mod diff: %q", a.GoModDiff)
+	}
+
+	text, err := os.ReadFile(filepath.Join(baseRepoPath, planTextFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"a\n  master (from master): " + oldHead[:12] + " -> ", "(source 0123456789ab)", "    tag v0.1.0\n", "b\n  master (from master): up to date\n"} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("expected %q in plan:\n%s", want, text)
+		}
+	}
+	if p.Report().Plan == nil {
+		t.Errorf("expected plan in the run report")
+	}
+}
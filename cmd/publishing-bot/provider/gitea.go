@@ -0,0 +1,162 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/golang/glog"
+)
+
+// giteaProvider implements the Gitea API, which Forgejo shares.
+type giteaProvider struct {
+	base
+	api *restClient
+}
+
+func (p *giteaProvider) IssueURL(org, repo string, issue int) string {
+	return fmt.Sprintf("https://%s/%s/%s/issues/%d", p.host, org, repo, issue)
+}
+
+func giteaRepoPath(org, repo string) string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(org), url.PathEscape(repo))
+}
+
+type giteaComment struct {
+	ID   int64 `json:"id"`
+	User struct {
+		ID int64 `json:"id"`
+	} `json:"user"`
+}
+
+func (p *giteaProvider) ReportOnIssue(ctx context.Context, org, repo string, issue int, body string) error {
+	var myself struct {
+		ID int64 `json:"id"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/user", nil, &myself); err != nil {
+		return fmt.Errorf("failed to get own user: %w", err)
+	}
+
+	repoPath := giteaRepoPath(org, repo)
+	issuePath := fmt.Sprintf("%s/issues/%d", repoPath, issue)
+	if err := p.api.do(ctx, http.MethodPatch, issuePath, map[string]string{"state": "open"}, nil); err != nil {
+		return fmt.Errorf("failed to reopen issue #%d: %w", issue, err)
+	}
+
+	var newComment giteaComment
+	if err := p.api.do(ctx, http.MethodPost, issuePath+"/comments", map[string]string{"body": body}, &newComment); err != nil {
+		return fmt.Errorf("failed to comment on issue #%d: %w", issue, err)
+	}
+
+	// delete all other comments from this user
+	var comments []giteaComment
+	if err := p.api.do(ctx, http.MethodGet, issuePath+"/comments", nil, &comments); err != nil {
+		return fmt.Errorf("failed to get comments of issue #%d: %w", issue, err)
+	}
+	for _, c := range comments {
+		if c.User.ID != myself.ID || c.ID == newComment.ID {
+			continue
+		}
+		glog.Infof("Deleting comment %d", c.ID)
+		if err := p.api.do(ctx, http.MethodDelete, fmt.Sprintf("%s/issues/comments/%d", repoPath, c.ID), nil, nil); err != nil {
+			return fmt.Errorf("failed to delete comment %d of issue #%d: %w", c.ID, issue, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *giteaProvider) CloseIssue(ctx context.Context, org, repo string, issue int) error {
+	issuePath := fmt.Sprintf("%s/issues/%d", giteaRepoPath(org, repo), issue)
+	if err := p.api.do(ctx, http.MethodPatch, issuePath, map[string]string{"state": "closed"}, nil); err != nil {
+		return fmt.Errorf("failed to close issue #%d: %w", issue, err)
+	}
+	return nil
+}
+
+type giteaIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+func (p *giteaProvider) FindIssue(ctx context.Context, org, repo, title, label string) (*Issue, error) {
+	query := url.Values{"state": {"all"}, "type": {"issues"}, "labels": {label}, "q": {title}, "limit": {"50"}}
+	var issues []giteaIssue
+	if err := p.api.do(ctx, http.MethodGet, giteaRepoPath(org, repo)+"/issues?"+query.Encode(), nil, &issues); err != nil {
+		return nil, fmt.Errorf("failed to list issues of %s/%s: %w", org, repo, err)
+	}
+	// newest first
+	for _, i := range issues {
+		if i.Title == title {
+			return &Issue{Number: i.Number, Open: i.State == "open"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// labelID returns the ID of the label with the given name, creating it if
+// necessary. Gitea only accepts IDs when creating issues.
+func (p *giteaProvider) labelID(ctx context.Context, org, repo, name string) (int64, error) {
+	type giteaLabel struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	var labels []giteaLabel
+	if err := p.api.do(ctx, http.MethodGet, giteaRepoPath(org, repo)+"/labels?limit=50", nil, &labels); err != nil {
+		return 0, fmt.Errorf("failed to list labels of %s/%s: %w", org, repo, err)
+	}
+	for _, l := range labels {
+		if l.Name == name {
+			return l.ID, nil
+		}
+	}
+	var label giteaLabel
+	if err := p.api.do(ctx, http.MethodPost, giteaRepoPath(org, repo)+"/labels", map[string]string{"name": name, "color": "#e11d21"}, &label); err != nil {
+		return 0, fmt.Errorf("failed to create label %q in %s/%s: %w", name, org, repo, err)
+	}
+	return label.ID, nil
+}
+
+func (p *giteaProvider) CreateIssue(ctx context.Context, org, repo, title, label, body string) (int, error) {
+	id, err := p.labelID(ctx, org, repo, label)
+	if err != nil {
+		return 0, err
+	}
+	var issue giteaIssue
+	options := map[string]interface{}{"title": title, "body": body, "labels": []int64{id}}
+	if err := p.api.do(ctx, http.MethodPost, giteaRepoPath(org, repo)+"/issues", options, &issue); err != nil {
+		return 0, fmt.Errorf("failed to create issue in %s/%s: %w", org, repo, err)
+	}
+	return issue.Number, nil
+}
+
+func (p *giteaProvider) CreateRepository(ctx context.Context, org, repo string) error {
+	options := map[string]string{"name": repo}
+	err := p.api.do(ctx, http.MethodPost, "/orgs/"+url.PathEscape(org)+"/repos", options, nil)
+	if isNotFound(err) {
+		// org is a user, hopefully the authenticated one
+		err = p.api.do(ctx, http.MethodPost, "/user/repos", options, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", org, repo, err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+type githubProvider struct {
+	base
+	ts oauth2.TokenSource
+	// app is true if ts returns GitHub App installation tokens.
+	app bool
+}
+
+// PushAuth returns the "x-access-token" login GitHub expects for installation
+// tokens.
+func (p *githubProvider) PushAuth() PushAuth {
+	if p.app {
+		return PushAuth{Machine: p.host, Login: "x-access-token"}
+	}
+	return p.base.PushAuth()
+}
+
+func (p *githubProvider) client(ctx context.Context) (*github.Client, error) {
+	if p.ts == nil {
+		return nil, errors.New("no github token configured")
+	}
+	tc := oauth2.NewClient(ctx, p.ts)
+	if p.host == "github.com" {
+		return github.NewClient(tc), nil
+	}
+	return github.NewEnterpriseClient(fmt.Sprintf("https://%s/api/v3/", p.host), fmt.Sprintf("https://%s/api/uploads/", p.host), tc)
+}
+
+// login returns the login of the authenticated user. GitHub Apps act as the
+// "<app-slug>[bot]" user, but their installation tokens cannot read it.
+func (p *githubProvider) login(ctx context.Context, client *github.Client) (string, error) {
+	if p.app {
+		tokens, ok := p.ts.(*appTokens)
+		if !ok {
+			return "", errors.New("no github app configured")
+		}
+		slug, err := tokens.app.slug(ctx)
+		if err != nil {
+			return "", err
+		}
+		return slug + "[bot]", nil
+	}
+
+	myself, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to get own user: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get own user: HTTP code %d", resp.StatusCode)
+	}
+	return myself.GetLogin(), nil
+}
+
+func (p *githubProvider) IssueURL(org, repo string, issue int) string {
+	return fmt.Sprintf("https://%s/%s/%s/issues/%d", p.host, org, repo, issue)
+}
+
+func (p *githubProvider) ReportOnIssue(ctx context.Context, org, repo string, issue int, body string) error {
+	client, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	// who am I?
+	myself, err := p.login(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	// create new newComment. The issue is reopened by prow.
+	body = "/reopen\n\n" + body
+	newComment, resp, err := client.Issues.CreateComment(ctx, org, repo, issue, &github.IssueComment{
+		Body: &body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to comment on issue #%d: %w", issue, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to comment on issue #%d: HTTP code %d", issue, resp.StatusCode)
+	}
+
+	// delete all other comments from this user
+	comments, resp, err := client.Issues.ListComments(ctx, org, repo, issue, &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get github comments of issue #%d: %w", issue, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get github comments of issue #%d: HTTP code %d", issue, resp.StatusCode)
+	}
+	for _, c := range comments {
+		if c.User.GetLogin() != myself {
+			glog.Infof("Skipping comment %d not by me, but %v", *c.ID, c.User.GetLogin())
+			continue
+		}
+		if *c.ID == *newComment.ID {
+			continue
+		}
+
+		glog.Infof("Deleting comment %d", *c.ID)
+		resp, err = client.Issues.DeleteComment(ctx, org, repo, *c.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete github comment %d of issue #%d: %w", *c.ID, issue, err)
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("failed to delete github comment %d of issue #%d: HTTP code %d", *c.ID, issue, resp.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+func (p *githubProvider) CloseIssue(ctx context.Context, org, repo string, issue int) error {
+	client, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, resp, err := client.Issues.Edit(ctx, org, repo, issue, &github.IssueRequest{
+		State: github.String("closed"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close issue #%d: %w", issue, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to close issue #%d: HTTP code %d", issue, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (p *githubProvider) FindIssue(ctx context.Context, org, repo, title, label string) (*Issue, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues, resp, err := client.Issues.ListByRepo(ctx, org, repo, &github.IssueListByRepoOptions{
+		State:       "all",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues of %s/%s: %w", org, repo, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list issues of %s/%s: HTTP code %d", org, repo, resp.StatusCode)
+	}
+	// newest first
+	for _, i := range issues {
+		if !i.IsPullRequest() && i.GetTitle() == title {
+			return &Issue{Number: i.GetNumber(), Open: i.GetState() == "open"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *githubProvider) CreateIssue(ctx context.Context, org, repo, title, label, body string) (int, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	issue, resp, err := client.Issues.Create(ctx, org, repo, &github.IssueRequest{
+		Title:  &title,
+		Body:   &body,
+		Labels: &[]string{label},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create issue in %s/%s: %w", org, repo, err)
+	}
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("failed to create issue in %s/%s: HTTP code %d", org, repo, resp.StatusCode)
+	}
+	return issue.GetNumber(), nil
+}
+
+func (p *githubProvider) CreateRepository(ctx context.Context, org, repo string) error {
+	client, err := p.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	// repositories of the authenticated user are created without org. Apps
+	// have no repositories of their own.
+	if !p.app {
+		myself, err := p.login(ctx, client)
+		if err != nil {
+			return err
+		}
+		if myself == org {
+			org = ""
+		}
+	}
+	_, resp, err := client.Repositories.Create(ctx, org, &github.Repository{Name: github.String(repo)})
+	if err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", org, repo, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to create repository %s/%s: HTTP code %d", org, repo, resp.StatusCode)
+	}
+	return nil
+}
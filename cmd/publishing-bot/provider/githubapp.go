@@ -0,0 +1,213 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// appTokenRefreshMargin is how long before expiry an installation token is
+// replaced. Tokens live for one hour, and a push must not outlive its token.
+const appTokenRefreshMargin = 10 * time.Minute
+
+// TokenSource returns the token source configured in cfg: GitHub App
+// installation tokens if an app is configured, the token file otherwise. It
+// returns nil if neither is configured.
+func TokenSource(cfg *config.Config) (oauth2.TokenSource, error) {
+	if cfg.GithubAppID == 0 {
+		if cfg.TokenFile == "" {
+			return nil, nil
+		}
+		return FileTokenSource(cfg.TokenFile), nil
+	}
+
+	if cfg.Provider != "" && cfg.Provider != config.ProviderGitHub {
+		return nil, fmt.Errorf("github-app-id is only supported with the github provider, not %q", cfg.Provider)
+	}
+	if cfg.TokenFile != "" {
+		return nil, errors.New("token-file and github-app-id are mutually exclusive")
+	}
+	if cfg.GithubAppInstallationID == 0 || cfg.GithubAppPrivateKeyFile == "" {
+		return nil, errors.New("github-app-id requires github-app-installation-id and github-app-private-key-file")
+	}
+	bs, err := os.ReadFile(cfg.GithubAppPrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load github app private key: %w", err)
+	}
+	key, err := parsePrivateKey(bs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse github app private key %q: %w", cfg.GithubAppPrivateKeyFile, err)
+	}
+
+	src := &appTokenSource{
+		apiURL:         githubAPIURL(cfg.GithubHost),
+		appID:          cfg.GithubAppID,
+		installationID: cfg.GithubAppInstallationID,
+		key:            key,
+		now:            time.Now,
+	}
+	return &appTokens{TokenSource: oauth2.ReuseTokenSourceWithExpiry(nil, src, appTokenRefreshMargin), app: src}, nil
+}
+
+// appTokens returns the installation tokens of a GitHub App, and identifies
+// the app, which the installation tokens cannot do.
+type appTokens struct {
+	oauth2.TokenSource
+	app *appTokenSource
+}
+
+func githubAPIURL(host string) string {
+	if host == "" || host == "github.com" {
+		return "https://api.github.com"
+	}
+	return fmt.Sprintf("https://%s/api/v3", host)
+}
+
+func parsePrivateKey(bs []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(bs)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected RSA key, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+// appTokenSource mints installation tokens of a GitHub App.
+type appTokenSource struct {
+	apiURL         string
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	now            func() time.Time
+}
+
+// jwt returns the RS256 signed token authenticating as the app itself.
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// backdated against clock drift, as recommended by GitHub
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	path := fmt.Sprintf("/app/installations/%d/access_tokens", s.installationID)
+	if err := s.do(ctx, http.MethodPost, path, http.StatusCreated, &result); err != nil {
+		return nil, fmt.Errorf("failed to get github app installation token: %w", err)
+	}
+	return &oauth2.Token{AccessToken: result.Token, TokenType: "Bearer", Expiry: result.ExpiresAt}, nil
+}
+
+// slug returns the URL-friendly name of the app, e.g. "publisher" for the
+// "publisher[bot]" user it acts as.
+func (s *appTokenSource) slug(ctx context.Context) (string, error) {
+	var result struct {
+		Slug string `json:"slug"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/app", http.StatusOK, &result); err != nil {
+		return "", fmt.Errorf("failed to get github app: %w", err)
+	}
+	if result.Slug == "" {
+		return "", errors.New("failed to get github app: no slug returned")
+	}
+	return result.Slug, nil
+}
+
+// do sends a request authenticated as the app itself, and decodes the
+// response into out.
+func (s *appTokenSource) do(ctx context.Context, method, path string, wantCode int, out interface{}) error {
+	jwt, err := s.jwt()
+	if err != nil {
+		return fmt.Errorf("failed to sign github app token: %w", err)
+	}
+
+	url := s.apiURL + path
+	req, err := http.NewRequestWithContext(ctx, method, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantCode {
+		//nolint:errcheck // the body is only informational
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &httpError{method: req.Method, url: url, code: resp.StatusCode, body: string(bs)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s: %w", method, url, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/app/installations/42/access_tokens":
+		case r.Method == http.MethodGet && r.URL.Path == "/app":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		// verify the JWT
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed jwt", http.StatusUnauthorized)
+			return
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		bs, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		var claims struct {
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+			Iss string `json:"iss"`
+		}
+		if err := json.Unmarshal(bs, &claims); err != nil || claims.Iss != "7" || claims.Iat >= now.Unix() || claims.Exp <= now.Unix() {
+			http.Error(w, "invalid claims "+string(bs), http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/app" {
+			//nolint:errcheck // test server
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 7, "slug": "publisher"})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		//nolint:errcheck // test server
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": "ghs_installation", "expires_at": expiresAt})
+	}))
+	defer s.Close()
+
+	src := &appTokenSource{
+		apiURL:         s.URL,
+		appID:          7,
+		installationID: 42,
+		key:            key,
+		now:            func() time.Time { return now },
+	}
+	tok, err := src.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "ghs_installation" {
+		t.Errorf("expected token %q, got %q", "ghs_installation", tok.AccessToken)
+	}
+	if !tok.Expiry.Equal(expiresAt) {
+		t.Errorf("expected expiry %v, got %v", expiresAt, tok.Expiry)
+	}
+
+	// the app acts as its bot user, without asking for it with the installation token
+	p := &githubProvider{ts: &appTokens{app: src}, app: true}
+	login, err := p.login(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login != "publisher[bot]" {
+		t.Errorf("expected login %q, got %q", "publisher[bot]", login)
+	}
+
+	src.installationID = 43
+	if _, err := src.Token(); err == nil {
+		t.Errorf("expected error for unknown installation")
+	}
+}
+
+func TestTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "nothing", cfg: config.Config{}, wantNil: true},
+		{name: "token file", cfg: config.Config{TokenFile: "/token"}},
+		{name: "app", cfg: config.Config{GithubAppID: 1, GithubAppInstallationID: 2, GithubAppPrivateKeyFile: keyFile}},
+		{name: "app without installation", cfg: config.Config{GithubAppID: 1, GithubAppPrivateKeyFile: keyFile}, wantErr: true},
+		{name: "app and token file", cfg: config.Config{GithubAppID: 1, GithubAppInstallationID: 2, GithubAppPrivateKeyFile: keyFile, TokenFile: "/token"}, wantErr: true},
+		{name: "app on gitlab", cfg: config.Config{Provider: config.ProviderGitLab, GithubAppID: 1, GithubAppInstallationID: 2, GithubAppPrivateKeyFile: keyFile}, wantErr: true},
+		{name: "app with missing key", cfg: config.Config{GithubAppID: 1, GithubAppInstallationID: 2, GithubAppPrivateKeyFile: keyFile + ".missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := TokenSource(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TokenSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (ts == nil) != tt.wantNil {
+				t.Errorf("TokenSource() = %v, wantNil %v", ts, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestGitHubAppPushAuth(t *testing.T) {
+	p, err := New(&config.Config{GithubAppID: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (PushAuth{Machine: "github.com", Login: "x-access-token"}); p.PushAuth() != want {
+		t.Errorf("PushAuth() = %+v, want %+v", p.PushAuth(), want)
+	}
+}
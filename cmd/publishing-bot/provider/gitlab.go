@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/golang/glog"
+)
+
+type gitlabProvider struct {
+	base
+	api *restClient
+}
+
+// PushAuth returns the "oauth2" login GitLab expects for tokens.
+func (p *gitlabProvider) PushAuth() PushAuth {
+	return PushAuth{Machine: p.host, Login: "oauth2"}
+}
+
+func (p *gitlabProvider) IssueURL(org, repo string, issue int) string {
+	return fmt.Sprintf("https://%s/%s/%s/-/issues/%d", p.host, org, repo, issue)
+}
+
+func gitlabIssuePath(org, repo string, issue int) string {
+	return fmt.Sprintf("/projects/%s/issues/%d", url.PathEscape(org+"/"+repo), issue)
+}
+
+type gitlabNote struct {
+	ID     int  `json:"id"`
+	System bool `json:"system"`
+	Author struct {
+		ID int `json:"id"`
+	} `json:"author"`
+}
+
+func (p *gitlabProvider) ReportOnIssue(ctx context.Context, org, repo string, issue int, body string) error {
+	var myself struct {
+		ID int `json:"id"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/user", nil, &myself); err != nil {
+		return fmt.Errorf("failed to get own user: %w", err)
+	}
+
+	issuePath := gitlabIssuePath(org, repo, issue)
+	if err := p.api.do(ctx, http.MethodPut, issuePath, map[string]string{"state_event": "reopen"}, nil); err != nil {
+		return fmt.Errorf("failed to reopen issue #%d: %w", issue, err)
+	}
+
+	var newNote gitlabNote
+	if err := p.api.do(ctx, http.MethodPost, issuePath+"/notes", map[string]string{"body": body}, &newNote); err != nil {
+		return fmt.Errorf("failed to comment on issue #%d: %w", issue, err)
+	}
+
+	// delete all other comments from this user
+	var notes []gitlabNote
+	if err := p.api.do(ctx, http.MethodGet, issuePath+"/notes?per_page=100", nil, &notes); err != nil {
+		return fmt.Errorf("failed to get comments of issue #%d: %w", issue, err)
+	}
+	for _, n := range notes {
+		if n.System || n.Author.ID != myself.ID || n.ID == newNote.ID {
+			continue
+		}
+		glog.Infof("Deleting comment %d", n.ID)
+		if err := p.api.do(ctx, http.MethodDelete, fmt.Sprintf("%s/notes/%d", issuePath, n.ID), nil, nil); err != nil {
+			return fmt.Errorf("failed to delete comment %d of issue #%d: %w", n.ID, issue, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *gitlabProvider) CloseIssue(ctx context.Context, org, repo string, issue int) error {
+	if err := p.api.do(ctx, http.MethodPut, gitlabIssuePath(org, repo, issue), map[string]string{"state_event": "close"}, nil); err != nil {
+		return fmt.Errorf("failed to close issue #%d: %w", issue, err)
+	}
+	return nil
+}
+
+type gitlabIssue struct {
+	IID   int    `json:"iid"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+func (p *gitlabProvider) FindIssue(ctx context.Context, org, repo, title, label string) (*Issue, error) {
+	query := url.Values{"labels": {label}, "search": {title}, "in": {"title"}, "per_page": {"100"}}
+	var issues []gitlabIssue
+	issuesPath := fmt.Sprintf("/projects/%s/issues?%s", url.PathEscape(org+"/"+repo), query.Encode())
+	if err := p.api.do(ctx, http.MethodGet, issuesPath, nil, &issues); err != nil {
+		return nil, fmt.Errorf("failed to list issues of %s/%s: %w", org, repo, err)
+	}
+	// newest first
+	for _, i := range issues {
+		if i.Title == title {
+			return &Issue{Number: i.IID, Open: i.State == "opened"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *gitlabProvider) CreateIssue(ctx context.Context, org, repo, title, label, body string) (int, error) {
+	var issue gitlabIssue
+	options := map[string]string{"title": title, "labels": label, "description": body}
+	if err := p.api.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/issues", url.PathEscape(org+"/"+repo)), options, &issue); err != nil {
+		return 0, fmt.Errorf("failed to create issue in %s/%s: %w", org, repo, err)
+	}
+	return issue.IID, nil
+}
+
+func (p *gitlabProvider) CreateRepository(ctx context.Context, org, repo string) error {
+	var namespace struct {
+		ID int `json:"id"`
+	}
+	if err := p.api.do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(org), nil, &namespace); err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", org, err)
+	}
+	project := map[string]interface{}{"name": repo, "path": repo, "namespace_id": namespace.ID}
+	if err := p.api.do(ctx, http.MethodPost, "/projects", project, nil); err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", org, repo, err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provider abstracts the git hosting the bot publishes to.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"golang.org/x/oauth2"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+const defaultCloneURLTemplate = "https://{{.Host}}/{{.Org}}/{{.Repo}}.git"
+
+// ErrNotSupported is returned by providers without an API for an operation.
+var ErrNotSupported = errors.New("not supported by the git provider")
+
+// Provider is a git hosting service.
+type Provider interface {
+	// CloneURL returns the URL to clone and push the given repository.
+	CloneURL(org, repo string) string
+	// PushAuth returns the netrc entry git pushes with.
+	PushAuth() PushAuth
+	// IssueURL returns the web URL of the given issue, or the empty string.
+	IssueURL(org, repo string, issue int) string
+	// ReportOnIssue reopens the issue and comments with the given body,
+	// deleting older comments of the bot.
+	ReportOnIssue(ctx context.Context, org, repo string, issue int, body string) error
+	// CloseIssue closes the given issue.
+	CloseIssue(ctx context.Context, org, repo string, issue int) error
+	// FindIssue returns the newest issue with the given title and label, or
+	// nil if there is none.
+	FindIssue(ctx context.Context, org, repo, title, label string) (*Issue, error)
+	// CreateIssue opens an issue with the given title, label and body, and
+	// returns its number.
+	CreateIssue(ctx context.Context, org, repo, title, label, body string) (int, error)
+	// CreateRepository creates an empty repository in the given org or user.
+	CreateRepository(ctx context.Context, org, repo string) error
+}
+
+// Issue is an issue found by title and label.
+type Issue struct {
+	Number int
+	Open   bool
+}
+
+// PushAuth describes the netrc entry for pushing with the token.
+type PushAuth struct {
+	// Machine is the host name of the netrc entry.
+	Machine string
+	// Login is the netrc login the token is the password for. If empty, the
+	// token is used as login.
+	Login string
+}
+
+// New returns the provider selected by the config. The token source is used
+// for API calls and may be nil if no API calls are done.
+func New(cfg *config.Config, ts oauth2.TokenSource) (Provider, error) {
+	host := cfg.GithubHost
+	if host == "" {
+		host = "github.com"
+	}
+	urlTemplate := cfg.CloneURLTemplate
+	if urlTemplate == "" {
+		urlTemplate = defaultCloneURLTemplate
+	}
+	tmpl, err := template.New("clone-url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clone-url-template %q: %w", urlTemplate, err)
+	}
+	b := base{host: host, cloneURL: tmpl}
+	if _, err := b.executeCloneURL("org", "repo"); err != nil {
+		return nil, fmt.Errorf("invalid clone-url-template %q: %w", urlTemplate, err)
+	}
+
+	switch cfg.Provider {
+	case "", config.ProviderGitHub:
+		return &githubProvider{base: b, ts: ts, app: cfg.GithubAppID != 0}, nil
+	case config.ProviderGitLab:
+		return &gitlabProvider{base: b, api: newRESTClient("https://"+host+"/api/v4", ts)}, nil
+	case config.ProviderGitea, config.ProviderForgejo:
+		return &giteaProvider{base: b, api: newRESTClient("https://"+host+"/api/v1", ts)}, nil
+	case config.ProviderGit:
+		return &gitProvider{base: b}, nil
+	default:
+		return nil, fmt.Errorf("unknown git provider %q", cfg.Provider)
+	}
+}
+
+// FileTokenSource returns a token source reading the token from the given
+// file on every call, such that the file can be rotated.
+func FileTokenSource(fileName string) oauth2.TokenSource {
+	return fileTokenSource(fileName)
+}
+
+type fileTokenSource string
+
+func (f fileTokenSource) Token() (*oauth2.Token, error) {
+	bs, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token file: %w", err)
+	}
+	return &oauth2.Token{AccessToken: strings.Trim(string(bs), " \t\n")}, nil
+}
+
+// base implements the parts common to all providers.
+type base struct {
+	host     string
+	cloneURL *template.Template
+}
+
+func (b *base) executeCloneURL(org, repo string) (string, error) {
+	var buf bytes.Buffer
+	err := b.cloneURL.Execute(&buf, struct{ Host, Org, Repo string }{b.host, org, repo})
+	return buf.String(), err
+}
+
+func (b *base) CloneURL(org, repo string) string {
+	//nolint:errcheck // the template is validated in New
+	u, _ := b.executeCloneURL(org, repo)
+	return u
+}
+
+func (b *base) PushAuth() PushAuth {
+	return PushAuth{Machine: b.host}
+}
+
+// gitProvider is a plain git remote without API.
+type gitProvider struct {
+	base
+}
+
+func (p *gitProvider) IssueURL(string, string, int) string {
+	return ""
+}
+
+func (p *gitProvider) ReportOnIssue(context.Context, string, string, int, string) error {
+	return fmt.Errorf("issue reporting is %w", ErrNotSupported)
+}
+
+func (p *gitProvider) CloseIssue(context.Context, string, string, int) error {
+	return fmt.Errorf("issue reporting is %w", ErrNotSupported)
+}
+
+func (p *gitProvider) FindIssue(context.Context, string, string, string, string) (*Issue, error) {
+	return nil, fmt.Errorf("issue reporting is %w", ErrNotSupported)
+}
+
+func (p *gitProvider) CreateIssue(context.Context, string, string, string, string, string) (int, error) {
+	return 0, fmt.Errorf("issue reporting is %w", ErrNotSupported)
+}
+
+func (p *gitProvider) CreateRepository(context.Context, string, string) error {
+	return fmt.Errorf("repository creation is %w", ErrNotSupported)
+}
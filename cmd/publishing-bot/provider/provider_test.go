@@ -0,0 +1,275 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		wantURL      string
+		wantAuth     PushAuth
+		wantIssueURL string
+		wantErr      bool
+	}{
+		{
+			name:         "default",
+			cfg:          config.Config{},
+			wantURL:      "https://github.com/org/repo.git",
+			wantAuth:     PushAuth{Machine: "github.com"},
+			wantIssueURL: "https://github.com/org/repo/issues/42",
+		},
+		{
+			name:         "gitlab",
+			cfg:          config.Config{Provider: config.ProviderGitLab, GithubHost: "gitlab.example.com"},
+			wantURL:      "https://gitlab.example.com/org/repo.git",
+			wantAuth:     PushAuth{Machine: "gitlab.example.com", Login: "oauth2"},
+			wantIssueURL: "https://gitlab.example.com/org/repo/-/issues/42",
+		},
+		{
+			name:         "forgejo",
+			cfg:          config.Config{Provider: config.ProviderForgejo, GithubHost: "git.example.com"},
+			wantURL:      "https://git.example.com/org/repo.git",
+			wantAuth:     PushAuth{Machine: "git.example.com"},
+			wantIssueURL: "https://git.example.com/org/repo/issues/42",
+		},
+		{
+			name:     "plain git with template",
+			cfg:      config.Config{Provider: config.ProviderGit, GithubHost: "git.example.com", CloneURLTemplate: "ssh://git@{{.Host}}/srv/{{.Org}}/{{.Repo}}"},
+			wantURL:  "ssh://git@git.example.com/srv/org/repo",
+			wantAuth: PushAuth{Machine: "git.example.com"},
+		},
+		{
+			name:    "invalid template",
+			cfg:     config.Config{CloneURLTemplate: "https://{{.Hostname}}/{{.Org}}/{{.Repo}}"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown provider",
+			cfg:     config.Config{Provider: "svn"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(&tt.cfg, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := p.CloneURL("org", "repo"); got != tt.wantURL {
+				t.Errorf("CloneURL() = %q, want %q", got, tt.wantURL)
+			}
+			if got := p.PushAuth(); got != tt.wantAuth {
+				t.Errorf("PushAuth() = %+v, want %+v", got, tt.wantAuth)
+			}
+			if got := p.IssueURL("org", "repo", 42); got != tt.wantIssueURL {
+				t.Errorf("IssueURL() = %q, want %q", got, tt.wantIssueURL)
+			}
+		})
+	}
+}
+
+func TestGitProviderNotSupported(t *testing.T) {
+	p, err := New(&config.Config{Provider: config.ProviderGit}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CreateRepository(context.Background(), "org", "repo"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestFileTokenSource(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(fileName, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := FileTokenSource(fileName).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "secret" {
+		t.Errorf("expected token %q, got %q", "secret", tok.AccessToken)
+	}
+}
+
+// fakeAPI records requests and answers them from a table keyed by
+// "<method> <path>".
+type fakeAPI struct {
+	lock      sync.Mutex
+	requests  []string
+	responses map[string]string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+		http.Error(w, "unauthorized "+got, http.StatusUnauthorized)
+		return
+	}
+	//nolint:errcheck // only for the record
+	body, _ := io.ReadAll(r.Body)
+	key := fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", key, body))
+	resp, ok := f.responses[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	//nolint:errcheck // test server
+	w.Write([]byte(resp))
+}
+
+func newFakeAPI(t *testing.T, responses map[string]string) (*fakeAPI, *restClient) {
+	t.Helper()
+	f := &fakeAPI{responses: responses}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+	return f, newRESTClient(s.URL, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret"}))
+}
+
+func TestGitLabReportOnIssue(t *testing.T) {
+	f, api := newFakeAPI(t, map[string]string{
+		"GET /user":                                             `{"id":7}`,
+		"PUT /projects/org%2Frepo/issues/42":                    `{}`,
+		"POST /projects/org%2Frepo/issues/42/notes":             `{"id":3}`,
+		"GET /projects/org%2Frepo/issues/42/notes?per_page=100": `[{"id":1,"author":{"id":7}},{"id":2,"author":{"id":8}},{"id":3,"author":{"id":7}},{"id":4,"system":true,"author":{"id":7}}]`,
+		"DELETE /projects/org%2Frepo/issues/42/notes/1":         ``,
+	})
+	p := &gitlabProvider{base: base{host: "gitlab.example.com"}, api: api}
+
+	if err := p.ReportOnIssue(context.Background(), "org", "repo", 42, "failed"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /user ",
+		`PUT /projects/org%2Frepo/issues/42 {"state_event":"reopen"}`,
+		`POST /projects/org%2Frepo/issues/42/notes {"body":"failed"}`,
+		"GET /projects/org%2Frepo/issues/42/notes?per_page=100 ",
+		"DELETE /projects/org%2Frepo/issues/42/notes/1 ",
+	}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("unexpected requests:\n%q\nexpected:\n%q", f.requests, want)
+	}
+}
+
+func TestGiteaCreateRepository(t *testing.T) {
+	f, api := newFakeAPI(t, map[string]string{
+		"POST /user/repos": `{}`,
+	})
+	p := &giteaProvider{base: base{host: "git.example.com"}, api: api}
+
+	if err := p.CreateRepository(context.Background(), "me", "repo"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`POST /orgs/me/repos {"name":"repo"}`,
+		`POST /user/repos {"name":"repo"}`,
+	}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("unexpected requests:\n%q\nexpected:\n%q", f.requests, want)
+	}
+}
+
+func TestGiteaCloseIssue(t *testing.T) {
+	f, api := newFakeAPI(t, map[string]string{
+		"PATCH /repos/org/repo/issues/42": `{}`,
+	})
+	p := &giteaProvider{base: base{host: "git.example.com"}, api: api}
+
+	if err := p.CloseIssue(context.Background(), "org", "repo", 42); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`PATCH /repos/org/repo/issues/42 {"state":"closed"}`}; !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("unexpected requests:\n%q\nexpected:\n%q", f.requests, want)
+	}
+}
+
+func TestGiteaFindAndCreateIssue(t *testing.T) {
+	f, api := newFakeAPI(t, map[string]string{
+		"GET /repos/org/repo/issues?labels=publishing-bot&limit=50&q=Publishing+failed&state=all&type=issues": `[{"number":3,"title":"Publishing failed again","state":"open"},{"number":2,"title":"Publishing failed","state":"closed"}]`,
+		"GET /repos/org/repo/labels?limit=50": `[{"id":5,"name":"bug"}]`,
+		"POST /repos/org/repo/labels":         `{"id":6,"name":"publishing-bot"}`,
+		"POST /repos/org/repo/issues":         `{"number":4}`,
+	})
+	p := &giteaProvider{base: base{host: "git.example.com"}, api: api}
+
+	issue, err := p.FindIssue(context.Background(), "org", "repo", "Publishing failed", "publishing-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Issue{Number: 2, Open: false}); issue == nil || *issue != want {
+		t.Errorf("expected issue %+v, got %+v", want, issue)
+	}
+
+	number, err := p.CreateIssue(context.Background(), "org", "repo", "Publishing failed", "publishing-bot", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number != 4 {
+		t.Errorf("expected issue #4, got #%d", number)
+	}
+	want := []string{
+		"GET /repos/org/repo/issues?labels=publishing-bot&limit=50&q=Publishing+failed&state=all&type=issues ",
+		"GET /repos/org/repo/labels?limit=50 ",
+		`POST /repos/org/repo/labels {"color":"#e11d21","name":"publishing-bot"}`,
+		`POST /repos/org/repo/issues {"body":"logs","labels":[6],"title":"Publishing failed"}`,
+	}
+	if !reflect.DeepEqual(f.requests, want) {
+		t.Errorf("unexpected requests:\n%q\nexpected:\n%q", f.requests, want)
+	}
+}
+
+func TestGitLabFindIssue(t *testing.T) {
+	_, api := newFakeAPI(t, map[string]string{
+		"GET /projects/org%2Frepo/issues?in=title&labels=publishing-bot&per_page=100&search=Publishing+failed": `[{"iid":9,"title":"Publishing failed","state":"opened"}]`,
+		"GET /projects/org%2Frepo/issues?in=title&labels=publishing-bot&per_page=100&search=Other":             `[]`,
+	})
+	p := &gitlabProvider{base: base{host: "gitlab.example.com"}, api: api}
+
+	issue, err := p.FindIssue(context.Background(), "org", "repo", "Publishing failed", "publishing-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Issue{Number: 9, Open: true}); issue == nil || *issue != want {
+		t.Errorf("expected issue %+v, got %+v", want, issue)
+	}
+	if issue, err := p.FindIssue(context.Background(), "org", "repo", "Other", "publishing-bot"); err != nil || issue != nil {
+		t.Errorf("expected no issue, got %+v, %v", issue, err)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// restClient is a minimal JSON client for the REST APIs of GitLab and Gitea.
+// Both accept the token as bearer token.
+type restClient struct {
+	baseURL string
+	ts      oauth2.TokenSource
+}
+
+func newRESTClient(baseURL string, ts oauth2.TokenSource) *restClient {
+	return &restClient{baseURL: baseURL, ts: ts}
+}
+
+// httpError is returned for non-2xx responses.
+type httpError struct {
+	method, url string
+	code        int
+	body        string
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s %s: HTTP code %d: %s", e.method, e.url, e.code, e.body)
+}
+
+func isNotFound(err error) bool {
+	var httpErr *httpError
+	return errors.As(err, &httpErr) && httpErr.code == http.StatusNotFound
+}
+
+// do sends in as JSON body, if not nil, and decodes the response into out, if
+// not nil.
+func (c *restClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	if c.ts == nil {
+		return errors.New("no token configured")
+	}
+
+	var body io.Reader = http.NoBody
+	if in != nil {
+		bs, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(bs)
+	}
+	url := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oauth2.NewClient(ctx, c.ts).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		//nolint:errcheck // the body is only informational
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &httpError{method: method, url: url, code: resp.StatusCode, body: string(bs)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, url, err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s in %s failed: %v\n%s", strings.Join(args, " "), dir, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupPublishRepo creates a bare remote for repo with one commit and a clone
+// in baseRepoPath with one more local commit. It returns the remote directory
+// and the old remote head.
+func setupPublishRepo(t *testing.T, baseRepoPath, remotes, repo string) (string, string) {
+	t.Helper()
+	remote := filepath.Join(remotes, repo+".git")
+	gitCmd(t, remotes, "init", "-q", "--bare", "-b", "master", remote)
+
+	seed := filepath.Join(remotes, repo+"-seed")
+	gitCmd(t, remotes, "clone", "-q", remote, seed)
+	gitCmd(t, seed, "commit", "-q", "--allow-empty", "-m", "initial")
+	gitCmd(t, seed, "push", "-q", "origin", "master")
+	oldHead := gitCmd(t, seed, "rev-parse", "HEAD")
+
+	gitCmd(t, baseRepoPath, "clone", "-q", remote, repo)
+	gitCmd(t, filepath.Join(baseRepoPath, repo), "commit", "-q", "--allow-empty", "-m", "published")
+
+	pushTags := filepath.Join(baseRepoPath, pushTagsFileName(repo, "master"))
+	if err := os.WriteFile(pushTags, []byte("#!/bin/bash\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return remote, oldHead
+}
+
+func newPublishTestMunger(t *testing.T, baseRepoPath string, repos ...string) *PublisherMunger {
+	t.Helper()
+	scripts, err := filepath.Abs("../../artifacts/scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Provider: config.ProviderGit, BasePublishScriptPath: scripts}
+	prov, err := provider.New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(cfg, baseRepoPath, prov, nil, nil)
+	if p.plog, err = newPublisherLog(&bytes.Buffer{}, nil, filepath.Join(baseRepoPath, "run.log"), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range repos {
+		p.reposRules.Rules = append(p.reposRules.Rules, config.RepositoryRule{
+			DestinationRepository: repo,
+			Branches:              []config.BranchRule{{Name: "master", Source: config.Source{Branch: "master"}}},
+		})
+	}
+	return p
+}
+
+func TestPublish(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "bot")
+	t.Setenv("GIT_AUTHOR_EMAIL", "bot@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "bot")
+	t.Setenv("GIT_COMMITTER_EMAIL", "bot@example.com")
+	upstreamHeads := map[string]plumbing.Hash{"master": plumbing.NewHash("1111111111111111111111111111111111111111")}
+
+	t.Run("success", func(t *testing.T) {
+		baseRepoPath, remotes := t.TempDir(), t.TempDir()
+		remoteA, _ := setupPublishRepo(t, baseRepoPath, remotes, "a")
+		remoteB, _ := setupPublishRepo(t, baseRepoPath, remotes, "b")
+
+		p := newPublishTestMunger(t, baseRepoPath, "a", "b")
+		if err := p.publish(upstreamHeads); err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, p.plog.Logs())
+		}
+		for repo, remote := range map[string]string{"a": remoteA, "b": remoteB} {
+			if got, want := gitCmd(t, remote, "rev-parse", "master"), gitCmd(t, filepath.Join(baseRepoPath, repo), "rev-parse", "master"); got != want {
+				t.Errorf("expected remote %s at %s, got %s", repo, want, got)
+			}
+			if pending := gitCmd(t, remote, "for-each-ref", "refs/publishing-bot"); pending != "" {
+				t.Errorf("expected no pending refs in %s, got %q", repo, pending)
+			}
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		baseRepoPath, remotes := t.TempDir(), t.TempDir()
+		remoteA, oldHeadA := setupPublishRepo(t, baseRepoPath, remotes, "a")
+		remoteB, _ := setupPublishRepo(t, baseRepoPath, remotes, "b")
+
+		// let b diverge remotely, such that the fast-forward fails
+		seedB := filepath.Join(remotes, "b-seed")
+		gitCmd(t, seedB, "commit", "-q", "--allow-empty", "-m", "concurrent")
+		gitCmd(t, seedB, "push", "-q", "origin", "master")
+		concurrentB := gitCmd(t, seedB, "rev-parse", "HEAD")
+
+		p := newPublishTestMunger(t, baseRepoPath, "a", "b")
+		if err := p.publish(upstreamHeads); err == nil {
+			t.Fatalf("expected error\n%s", p.plog.Logs())
+		}
+		if got := gitCmd(t, remoteA, "rev-parse", "master"); got != oldHeadA {
+			t.Errorf("expected a to be rolled back to %s, got %s", oldHeadA, got)
+		}
+		if got := gitCmd(t, remoteB, "rev-parse", "master"); got != concurrentB {
+			t.Errorf("expected b to stay at %s, got %s", concurrentB, got)
+		}
+		if pending := gitCmd(t, remoteA, "for-each-ref", "refs/publishing-bot"); pending != "" {
+			t.Errorf("expected no pending refs in a, got %q", pending)
+		}
+		if _, err := os.Stat(filepath.Join(baseRepoPath, publishedFileName("a", "master"))); !os.IsNotExist(err) {
+			t.Errorf("expected no published file for a, got: %v", err)
+		}
+
+		got := map[string]string{}
+		for _, rr := range p.Report().Repositories {
+			for _, br := range rr.Branches {
+				got[rr.Name] = br.Push
+			}
+		}
+		if got["a"] != ResultRolledBack || got["b"] != ResultFailed {
+			t.Errorf("unexpected push results: %v", got)
+		}
+	})
+
+	t.Run("tags failure after promotion", func(t *testing.T) {
+		baseRepoPath, remotes := t.TempDir(), t.TempDir()
+		remoteA, _ := setupPublishRepo(t, baseRepoPath, remotes, "a")
+		remoteB, _ := setupPublishRepo(t, baseRepoPath, remotes, "b")
+
+		pushTags := filepath.Join(baseRepoPath, pushTagsFileName("a", "master"))
+		if err := os.WriteFile(pushTags, []byte("#!/bin/bash\nexit 1\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		p := newPublishTestMunger(t, baseRepoPath, "a", "b")
+		if err := p.publish(upstreamHeads); err == nil {
+			t.Fatalf("expected error\n%s", p.plog.Logs())
+		}
+		// branches are not rolled back after promotion
+		for repo, remote := range map[string]string{"a": remoteA, "b": remoteB} {
+			if got, want := gitCmd(t, remote, "rev-parse", "master"), gitCmd(t, filepath.Join(baseRepoPath, repo), "rev-parse", "master"); got != want {
+				t.Errorf("expected %s to stay promoted at %s, got %s", repo, want, got)
+			}
+			if _, err := os.Stat(filepath.Join(baseRepoPath, publishedFileName(repo, "master"))); !os.IsNotExist(err) {
+				t.Errorf("expected no published file for %s, got: %v", repo, err)
+			}
+		}
+
+		got := map[string]*BranchReport{}
+		for _, rr := range p.Report().Repositories {
+			for _, br := range rr.Branches {
+				got[rr.Name] = br
+			}
+		}
+		if got["a"] == nil || got["a"].Push != ResultTagsFailed || got["a"].Error == "" {
+			t.Errorf("expected a to be reported with failed tags, got %+v", got["a"])
+		}
+		if got["b"] == nil || got["b"].Push != ResultPromoted || got["b"].Error != "" {
+			t.Errorf("expected b to be reported as promoted only, got %+v", got["b"])
+		}
+	})
+
+	t.Run("isolated", func(t *testing.T) {
+		baseRepoPath, remotes := t.TempDir(), t.TempDir()
+		remoteA, _ := setupPublishRepo(t, baseRepoPath, remotes, "a")
+		setupPublishRepo(t, baseRepoPath, remotes, "b")
+		remoteC, oldHeadC := setupPublishRepo(t, baseRepoPath, remotes, "c")
+
+		// let b diverge remotely, such that the fast-forward fails
+		seedB := filepath.Join(remotes, "b-seed")
+		gitCmd(t, seedB, "commit", "-q", "--allow-empty", "-m", "concurrent")
+		gitCmd(t, seedB, "push", "-q", "origin", "master")
+
+		p := newPublishTestMunger(t, baseRepoPath, "a", "b", "c")
+		p.reposRules.Rules[2].Branches[0].Dependencies = []config.Dependency{{Repository: "b", Branch: "master"}}
+		p.failures = newFailureTracker(&p.reposRules)
+		if err := p.publish(upstreamHeads); err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, p.plog.Logs())
+		}
+		if got, want := gitCmd(t, remoteA, "rev-parse", "master"), gitCmd(t, filepath.Join(baseRepoPath, "a"), "rev-parse", "master"); got != want {
+			t.Errorf("expected independent a to be published at %s, got %s", want, got)
+		}
+		if got := gitCmd(t, remoteC, "rev-parse", "master"); got != oldHeadC {
+			t.Errorf("expected dependent c to stay at %s, got %s", oldHeadC, got)
+		}
+		if _, err := os.Stat(filepath.Join(baseRepoPath, publishedFileName("c", "master"))); !os.IsNotExist(err) {
+			t.Errorf("expected no published file for c, got: %v", err)
+		}
+
+		failures := p.failures.list()
+		if len(failures) != 2 || failures[0].Repository != "b" || failures[0].Phase != PhasePublish ||
+			failures[1] != (RepositoryFailure{Repository: "c", Dependency: "b"}) {
+			t.Errorf("unexpected failures: %+v", failures)
+		}
+	})
+}
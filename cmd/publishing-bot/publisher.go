@@ -18,19 +18,24 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/golang/glog"
+	"golang.org/x/oauth2"
 	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
 	"k8s.io/publishing-bot/pkg/golang"
 )
 
@@ -38,18 +43,48 @@ import (
 type PublisherMunger struct {
 	reposRules config.RepositoryRules
 	config     *config.Config
+	provider   provider.Provider
+	// tokenSource authenticates pushes. It is nil without token.
+	tokenSource oauth2.TokenSource
 	// plog duplicates the logs at glog and a file
 	plog *plog
+	// redactor scrubs the secrets of the current run from logs and reports.
+	redactor *redactor
 	// absolute path to the repos.
 	baseRepoPath string
+
+	// report collects the report of the current run.
+	report *runReporter
+	// metrics are updated during the run. They may be nil.
+	metrics *publisherMetrics
+	// triggers are the causes of the run, or empty if it was started by the
+	// interval.
+	triggers []*RunTrigger
+	// sourceBranches are the source branches of the rules of the last run.
+	sourceBranches map[string]bool
+	// logStream copies the logs of the run to live viewers. It may be nil.
+	logStream *logBroadcaster
+	// checkpoint records the constructed branches, such that a retry after a
+	// failure does not construct them again.
+	checkpoint *checkpoint
+	// failures records failed repositories if failures are isolated, nil
+	// otherwise.
+	failures *failureTracker
+	// tagsDigest is the upstreamTagsDigest of the source repository in the
+	// current run, or empty if it was not computed.
+	tagsDigest string
 }
 
 // New will create a new munger.
-func New(cfg *config.Config, baseRepoPath string) *PublisherMunger {
+func New(cfg *config.Config, baseRepoPath string, prov provider.Provider, tokenSource oauth2.TokenSource, metrics *publisherMetrics) *PublisherMunger {
 	// create munger
 	return &PublisherMunger{
 		baseRepoPath: baseRepoPath,
 		config:       cfg,
+		provider:     prov,
+		tokenSource:  tokenSource,
+		report:       newRunReporter(nil),
+		metrics:      metrics,
 	}
 }
 
@@ -178,45 +213,48 @@ func (p *PublisherMunger) skippedBranch(b string) bool {
 }
 
 // git clone dstURL to dst if dst doesn't exist yet.
-func (p *PublisherMunger) ensureCloned(dst, dstURL string) error {
+func (p *PublisherMunger) ensureCloned(log *plog, dst, dstURL string) error {
 	if _, err := os.Stat(dst); err == nil {
 		return nil
 	}
 
 	cmd := exec.Command("mkdir", "-p", dst)
-	if err := p.plog.Run(cmd); err != nil {
+	if err := log.Run(cmd); err != nil {
 		return err
 	}
 	cmd = exec.Command("git", "clone", dstURL, dst)
-	if err := p.plog.Run(cmd); err != nil {
+	if err := log.Run(cmd); err != nil {
 		return err
 	}
 	cmd = exec.Command("/bin/bash", "-c", "git tag -l | xargs git tag -d")
 	cmd.Dir = dst
-	return p.plog.Run(cmd)
+	return log.Run(cmd)
 }
 
-func (p *PublisherMunger) runSmokeTests(smokeTest, oldHead, newHead string, branchEnv []string) error {
+func (p *PublisherMunger) runSmokeTests(log *plog, dir, smokeTest, oldHead, newHead string, branchEnv []string) error {
 	if smokeTest != "" && oldHead != newHead {
 		cmd := exec.Command("/bin/bash", "-xec", smokeTest)
+		cmd.Dir = dir
 		cmd.Env = append([]string(nil), branchEnv...) // make mutable
 		cmd.Env = append(
 			cmd.Env,
 			"GO111MODULE=on",
 			fmt.Sprintf("GOPROXY=file://%s/pkg/mod/cache/download", os.Getenv("GOPATH")),
 		)
-		if err := p.plog.Run(cmd); err != nil {
+		if err := log.Run(cmd); err != nil {
 			// do not clean up to allow debugging with kubectl-exec.
 			return err
 		}
 
-		err := exec.Command("git", "reset", "--hard").Run()
-		if err != nil {
+		cmd = exec.Command("git", "reset", "--hard")
+		cmd.Dir = dir
+		if err := cmd.Run(); err != nil {
 			return err
 		}
 
-		err = exec.Command("git", "clean", "-f", "-f", "-d").Run()
-		if err != nil {
+		cmd = exec.Command("git", "clean", "-f", "-f", "-d")
+		cmd.Dir = dir
+		if err := cmd.Run(); err != nil {
 			return err
 		}
 	}
@@ -238,124 +276,289 @@ func (p *PublisherMunger) construct() error {
 		}
 
 		// clone the destination repo
+		log := p.plog.with(logFields{Repo: repoRule.DestinationRepository, Phase: logPhaseConstruct})
 		dstDir := filepath.Join(p.baseRepoPath, repoRule.DestinationRepository, "")
-		dstURL := fmt.Sprintf("https://%s/%s/%s.git", p.config.GithubHost, p.config.TargetOrg, repoRule.DestinationRepository)
-		if err := p.ensureCloned(dstDir, dstURL); err != nil {
-			p.plog.Errorf("%v", err)
+		dstURL := p.provider.CloneURL(p.config.TargetOrg, repoRule.DestinationRepository)
+		if err := p.ensureCloned(log, dstDir, dstURL); err != nil {
+			log.Errorf("%v", err)
+			if p.failures != nil {
+				p.failures.fail(repoRule.DestinationRepository, "", PhaseConstruct, err)
+				continue
+			}
 			return err
 		}
-		p.plog.Infof("Successfully ensured %s exists", dstDir)
-		if err := os.Chdir(dstDir); err != nil {
+		log.Infof("Successfully ensured %s exists", dstDir)
+
+		// delete tags, except those created for checkpointed branches and not pushed yet
+		args := append([]string{"-c", `git tag | grep -vxF -f <(printf '%s\n' "$@") | xargs -r git tag -d >/dev/null`, "bash"},
+			p.checkpoint.tags(repoRule.DestinationRepository)...)
+		cmd := exec.Command("/bin/bash", args...)
+		cmd.Dir = dstDir
+		if err := log.Run(cmd); err != nil {
+			if p.failures != nil {
+				p.failures.fail(repoRule.DestinationRepository, "", PhaseConstruct, err)
+				continue
+			}
 			return err
 		}
+	}
 
-		// delete tags
-		cmd := exec.Command("/bin/bash", "-c", "git tag | xargs git tag -d >/dev/null")
-		if err := p.plog.Run(cmd); err != nil {
-			return err
+	// construct branches
+	jobs := constructJobs(&p.reposRules, p.skippedBranch)
+	p.plog.Infof("Constructing %d branches with %d workers", len(jobs), p.config.ConstructWorkers)
+	return runConstructJobs(jobs, p.config.ConstructWorkers, func(j *constructJob) error {
+		if p.failures == nil {
+			return p.constructBranch(sourceRemote, j.repoRule, j.branchRule)
 		}
 
-		formatDeps := func(deps []config.Dependency) string {
-			var depStrings []string
-			for _, dep := range deps {
-				depStrings = append(depStrings, fmt.Sprintf("%s:%s", dep.Repository, dep.Branch))
-			}
-			return strings.Join(depStrings, ",")
+		// isolate failures to the repository and its dependents
+		repo := j.repoRule.DestinationRepository
+		log := p.plog.with(logFields{Repo: repo, Branch: j.branchRule.Name, Phase: logPhaseConstruct})
+		if p.failures.isFailed(repo) {
+			log.Infof("Skipping %s, the repository or one of its dependencies failed", j)
+			return nil
 		}
+		if err := p.constructBranch(sourceRemote, j.repoRule, j.branchRule); err != nil {
+			log.Errorf("Failed to construct %s: %v", j, err)
+			p.failures.fail(repo, j.branchRule.Name, PhaseConstruct, err)
+		}
+		return nil
+	})
+}
 
-		// construct branches
-		for i := range repoRule.Branches {
-			branchRule := repoRule.Branches[i]
-			if p.skippedBranch(branchRule.Source.Branch) {
-				continue
-			}
-			if len(branchRule.Source.Dirs) == 0 {
-				branchRule.Source.Dirs = append(branchRule.Source.Dirs, ".")
-				p.plog.Infof("%v: 'dir' cannot be empty, defaulting to '.'", branchRule)
+// constructBranch runs the construct script for one branch of a destination
+// repository. It does not change the working directory of the process, such
+// that branches of independent repositories can be constructed concurrently.
+func (p *PublisherMunger) constructBranch(sourceRemote string, repoRule *config.RepositoryRule, branchRule config.BranchRule) (err error) {
+	dstDir := filepath.Join(p.baseRepoPath, repoRule.DestinationRepository, "")
+	log := p.plog.with(logFields{Repo: repoRule.DestinationRepository, Branch: branchRule.Name, Phase: logPhaseConstruct})
+
+	start := time.Now()
+	p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+		br.SourceBranch = branchRule.Source.Branch
+		br.Push = ResultSkipped
+	})
+	defer func() {
+		p.metrics.observeConstruct(repoRule.DestinationRepository, time.Since(start))
+		p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+			br.DurationSeconds += time.Since(start).Seconds()
+			if err != nil {
+				br.Error = err.Error()
 			}
+		})
+	}()
 
-			//nolint:errcheck // get old HEAD. Ignore errors as the branch might be non-existent
-			oldHead, _ := exec.Command("git", "rev-parse", fmt.Sprintf("origin/%s", branchRule.Name)).Output()
+	digest, err := p.rulesDigest(repoRule)
+	if err != nil {
+		return err
+	}
+	if cp, ok := p.checkpoint.lookup(repoRule.DestinationRepository, branchRule.Name, digest); ok {
+		head, err := gitRevParse(dstDir, "refs/heads/"+branchRule.Name)
+		if err != nil {
+			return err
+		}
+		if head == cp.Head {
+			log.Infof("Skipping %s/%s, constructed at %s by an earlier attempt", repoRule.DestinationRepository, branchRule.Name, head)
+			p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+				br.OldHead = cp.Report.OldHead
+				br.NewHead = cp.Report.NewHead
+				br.CherryPicked = cp.Report.CherryPicked
+				br.TagsCreated = cp.Report.TagsCreated
+				br.StaleTags = cp.Report.StaleTags
+				br.SmokeTest = cp.Report.SmokeTest
+				br.Resumed = true
+			})
+			return nil
+		}
+	}
 
-			goPath := os.Getenv("GOPATH")
-			branchEnv := append([]string(nil), os.Environ()...) // make mutable
-			if branchRule.GoVersion != "" {
-				goRoot := filepath.Join(goPath, "go-"+branchRule.GoVersion)
-				branchEnv = append(branchEnv, "GOROOT="+goRoot)
-				goBin := filepath.Join(goRoot, "bin")
-				branchEnv = updateEnv(branchEnv, "PATH", prependPath(goBin), goBin)
-			}
+	formatDeps := func(deps []config.Dependency) string {
+		var depStrings []string
+		for _, dep := range deps {
+			depStrings = append(depStrings, fmt.Sprintf("%s:%s", dep.Repository, dep.Branch))
+		}
+		return strings.Join(depStrings, ",")
+	}
 
-			skipTags := ""
-			if p.reposRules.SkipTags {
-				skipTags = "true"
-				p.plog.Infof("synchronizing tags is disabled")
-			}
+	if len(branchRule.Source.Dirs) == 0 {
+		branchRule.Source.Dirs = append(branchRule.Source.Dirs, ".")
+		log.Infof("%v: 'dir' cannot be empty, defaulting to '.'", branchRule)
+	}
 
-			skipNonSemverTags := "false"
-			if p.reposRules.SkipNonSemverTags {
-				skipNonSemverTags = "true"
-				p.plog.Infof("synchronizing non-semver tags is disabled")
-			}
+	// get old HEAD. Ignore errors as the branch might be non-existent
+	revParse := exec.Command("git", "rev-parse", fmt.Sprintf("origin/%s", branchRule.Name))
+	revParse.Dir = dstDir
+	//nolint:errcheck // see above
+	oldHead, _ := revParse.Output()
+
+	goPath := os.Getenv("GOPATH")
+	branchEnv := append([]string(nil), os.Environ()...) // make mutable
+	if branchRule.GoVersion != "" {
+		goRoot := filepath.Join(goPath, "go-"+branchRule.GoVersion)
+		branchEnv = append(branchEnv, "GOROOT="+goRoot)
+		goBin := filepath.Join(goRoot, "bin")
+		branchEnv = updateEnv(branchEnv, "PATH", prependPath(goBin), goBin)
+	}
 
-			// get old published hash to eventually skip cherry picking
-			var lastPublishedUpstreamHash string
-			bs, err := os.ReadFile(path.Join(p.baseRepoPath, publishedFileName(repoRule.DestinationRepository, branchRule.Name)))
-			if err != nil && !os.IsNotExist(err) {
-				return err
-			}
-			if err == nil {
-				lastPublishedUpstreamHash = string(bs)
-			}
+	skipTags := ""
+	if p.reposRules.SkipTags {
+		skipTags = "true"
+		log.Infof("synchronizing tags is disabled")
+	}
 
-			// TODO: Refactor this to use environment variables instead
-			repoPublishScriptPath := filepath.Join(p.config.BasePublishScriptPath, "construct.sh")
-			cmd := exec.Command(repoPublishScriptPath,
-				repoRule.DestinationRepository,
-				branchRule.Source.Branch,
-				branchRule.Name,
-				formatDeps(branchRule.Dependencies),
-				strings.Join(branchRule.RequiredPackages, ":"),
-				sourceRemote,
-				strings.Join(branchRule.Source.Dirs, ":"),
-				p.config.SourceRepo,
-				p.config.SourceRepo,
-				p.config.BasePackage,
-				strconv.FormatBool(repoRule.Library),
-				strings.Join(p.reposRules.RecursiveDeletePatterns, " "),
-				skipTags,
-				skipNonSemverTags,
-				repoRule.DestinationTagBase,
-				lastPublishedUpstreamHash,
-				p.config.GitDefaultBranch,
-			)
-			cmd.Env = append([]string(nil), branchEnv...) // make mutable
-			if p.reposRules.SkipGomod {
-				cmd.Env = append(cmd.Env, "PUBLISHER_BOT_SKIP_GOMOD=true")
-			}
-			if err := p.plog.Run(cmd); err != nil {
-				return err
-			}
+	skipNonSemverTags := "false"
+	if p.reposRules.SkipNonSemverTags {
+		skipNonSemverTags = "true"
+		log.Infof("synchronizing non-semver tags is disabled")
+	}
 
-			//nolint:errcheck  // TODO(lint): Should we be checking errors here?
-			newHead, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	// get old published hash to eventually skip cherry picking
+	var lastPublishedUpstreamHash string
+	bs, err := os.ReadFile(path.Join(p.baseRepoPath, publishedFileName(repoRule.DestinationRepository, branchRule.Name)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		lastPublishedUpstreamHash = string(bs)
+	}
 
-			p.plog.Infof("Running branch-specific smoke tests for branch %s", branchRule.Name)
-			if err := p.runSmokeTests(branchRule.SmokeTest, string(oldHead), string(newHead), branchEnv); err != nil {
-				return err
-			}
+	// construct.sh filters the source history with the /filter-branch tool,
+	// i.e. git.FilterBranch, between the git steps of the script.
+	// TODO: Refactor this to use environment variables instead
+	repoPublishScriptPath := filepath.Join(p.config.BasePublishScriptPath, "construct.sh")
+	cmd := exec.Command(repoPublishScriptPath,
+		repoRule.DestinationRepository,
+		branchRule.Source.Branch,
+		branchRule.Name,
+		formatDeps(branchRule.Dependencies),
+		strings.Join(branchRule.RequiredPackages, ":"),
+		sourceRemote,
+		strings.Join(branchRule.Source.DirSpecs(), ":"),
+		p.config.SourceOrg,
+		p.config.SourceRepo,
+		p.config.BasePackage,
+		strconv.FormatBool(repoRule.Library),
+		strings.Join(p.reposRules.RecursiveDeletePatterns, " "),
+		skipTags,
+		skipNonSemverTags,
+		repoRule.DestinationTagBase,
+		lastPublishedUpstreamHash,
+		p.config.GitDefaultBranch,
+	)
+	cmd.Dir = dstDir
+	cmd.Env = append([]string(nil), branchEnv...) // make mutable
+	if p.reposRules.SkipGomod {
+		cmd.Env = append(cmd.Env, "PUBLISHER_BOT_SKIP_GOMOD=true")
+	}
+	cmd.Env = append(cmd.Env, "PUBLISHER_BOT_SOURCE_HOST="+p.config.SourceRepoHost())
+	if tpl := p.reposRules.TagMessageTemplateFor(repoRule); tpl != "" {
+		cmd.Env = append(cmd.Env, "PUBLISHER_BOT_TAG_MESSAGE_TEMPLATE="+tpl)
+	}
+	if len(repoRule.TagMappings) > 0 {
+		bs, err := json.Marshal(repoRule.TagMappings)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tag mappings: %w", err)
+		}
+		cmd.Env = append(cmd.Env, "PUBLISHER_BOT_TAG_MAPPINGS="+string(bs))
+	}
+	if policy := p.reposRules.TagPolicy; policy != (config.TagPolicy{}) {
+		cmd.Env = append(cmd.Env,
+			"PUBLISHER_BOT_MIN_TAG_DATE="+policy.MinDate,
+			"PUBLISHER_BOT_MAX_TAGS="+strconv.Itoa(policy.MaxTagsPerRun),
+			"PUBLISHER_BOT_ONLY_NEWER_TAGS="+strconv.FormatBool(policy.OnlyNewerThanPublished),
+		)
+	}
+	if p.reposRules.LightweightTags {
+		cmd.Env = append(cmd.Env, "PUBLISHER_BOT_LIGHTWEIGHT_TAGS=true")
+	}
+	if p.reposRules.ReconcileTags {
+		cmd.Env = append(cmd.Env, "PUBLISHER_BOT_RECONCILE_TAGS=true")
+		if p.config.RewriteTags {
+			cmd.Env = append(cmd.Env, "PUBLISHER_BOT_REWRITE_TAGS=true")
+		}
+	}
+	if err := log.Run(cmd); err != nil {
+		return err
+	}
 
-			p.plog.Infof("Running repo-specific smoke tests for branch %s", branchRule.Name)
-			if err := p.runSmokeTests(repoRule.SmokeTest, string(oldHead), string(newHead), branchEnv); err != nil {
-				return err
-			}
+	revParse = exec.Command("git", "rev-parse", "HEAD")
+	revParse.Dir = dstDir
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	newHead, _ := revParse.Output()
 
-			p.plog.Infof("Successfully constructed %s", branchRule.Name)
+	cherryPicked, err := countCommits(dstDir, strings.TrimSpace(string(oldHead)), strings.TrimSpace(string(newHead)))
+	if err != nil {
+		return err
+	}
+	pushTags := filepath.Join(p.baseRepoPath, pushTagsFileName(repoRule.DestinationRepository, branchRule.Name))
+	tags, err := pushScriptTags(pushTags)
+	if err != nil {
+		return err
+	}
+	staleTags, err := pushScriptStaleTags(pushTags)
+	if err != nil {
+		return err
+	}
+	for _, st := range staleTags {
+		if st.Rewritten {
+			log.Infof("Tag %s of %s is stale, source tag %s was %s, rewriting it", st.Tag, repoRule.DestinationRepository, st.SourceTag, st.Reason)
+		} else {
+			log.Infof("Tag %s of %s is stale, source tag %s was %s", st.Tag, repoRule.DestinationRepository, st.SourceTag, st.Reason)
 		}
 	}
+	p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+		br.OldHead = strings.TrimSpace(string(oldHead))
+		br.NewHead = strings.TrimSpace(string(newHead))
+		br.CherryPicked = cherryPicked
+		br.TagsCreated = tags
+		br.StaleTags = staleTags
+		br.SmokeTest = ResultSkipped
+	})
+
+	for _, smokeTest := range []struct{ kind, script string }{{"branch", branchRule.SmokeTest}, {"repo", repoRule.SmokeTest}} {
+		if smokeTest.script == "" || string(oldHead) == string(newHead) {
+			continue
+		}
+		smokeLog := log.with(logFields{Phase: logPhaseSmoke})
+		smokeLog.Infof("Running %s-specific smoke tests for branch %s", smokeTest.kind, branchRule.Name)
+		err := p.runSmokeTests(smokeLog, dstDir, smokeTest.script, string(oldHead), string(newHead), branchEnv)
+		p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+			if err != nil {
+				br.SmokeTest = ResultFailed
+			} else {
+				br.SmokeTest = ResultPassed
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var constructed BranchReport
+	p.report.update(repoRule.DestinationRepository, branchRule.Name, func(br *BranchReport) {
+		constructed = *br
+	})
+	if err := p.checkpoint.record(repoRule.DestinationRepository, branchRule.Name, checkpointBranch{
+		RulesDigest: digest,
+		Head:        strings.TrimSpace(string(newHead)),
+		Report:      constructed,
+	}); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	log.Infof("Successfully constructed %s/%s", repoRule.DestinationRepository, branchRule.Name)
 	return nil
 }
 
+// commitMsgTag returns the commit message tag pointing back to source commits,
+// e.g. "Kubernetes-commit".
+func (p *PublisherMunger) commitMsgTag() string {
+	if p.config.SourceRepo == "" {
+		return "-commit"
+	}
+	return strings.ToUpper(p.config.SourceRepo[:1]) + p.config.SourceRepo[1:] + "-commit"
+}
+
 func updateEnv(env []string, key string, change func(string) string, val string) []string {
 	for i := range env {
 		if strings.HasPrefix(env[i], key+"=") {
@@ -376,98 +579,408 @@ func prependPath(p string) func(string) string {
 	}
 }
 
-// publish to remotes.
+// publishTarget is a destination branch pushed by publish.
+type publishTarget struct {
+	repo, branch, sourceBranch string
+	dir                        string
+	// oldHead is the remote head before publishing, empty for new branches.
+	oldHead, newHead string
+	promoted         bool
+	// duration is the time spent pushing.
+	duration time.Duration
+	log      *plog
+}
+
+// publish to remotes in two phases. All branches are first pushed to pending
+// refs and verified. Only then the real branches are fast-forwarded in rule
+// order, i.e. dependencies first. If that fails, the branches promoted so far
+// are rolled back to their old heads, such that no destination repository
+// references commits of a dependency that are not published. Tags are pushed
+// last. If that fails, the branches stay promoted and are reported as such.
 func (p *PublisherMunger) publish(newUpstreamHeads map[string]plumbing.Hash) error {
 	if p.config.DryRun {
 		p.plog.Infof("Skipping push in dry-run mode")
-		return nil
+		plan, err := p.plan(newUpstreamHeads)
+		if err != nil {
+			return fmt.Errorf("failed to compute dry-run plan: %w", err)
+		}
+		p.plog.Infof("Dry-run plan:\n%s", plan)
+		p.report.updateRun(func(report *RunReport) {
+			report.Plan = plan
+		})
+		return writePlan(p.baseRepoPath, plan)
 	}
 
-	if p.config.TokenFile == "" {
+	// plain git remotes might be authenticated by other means than a token
+	if p.tokenSource == nil && p.config.Provider != config.ProviderGit {
 		return errors.New("token cannot be empty in non-dry-run mode")
 	}
+	pushAuth := p.provider.PushAuth()
 
-	// NOTE: because some repos depend on each other, e.g., client-go depends on
-	// apimachinery, they should be published atomically, but it's not supported
-	// by github.
-	for _, repoRules := range p.reposRules.Rules {
-		if repoRules.Skip {
-			continue
+	// push.sh reads the token from a file. Installation tokens of a GitHub App
+	// expire, hence they are written to a private file before every push.
+	tokenFile := p.config.TokenFile
+	if p.config.GithubAppID != 0 {
+		f, err := os.CreateTemp("", "publishing-bot-token-")
+		if err != nil {
+			return err
 		}
-
-		dstDir := filepath.Join(p.baseRepoPath, repoRules.DestinationRepository, "")
-		if err := os.Chdir(dstDir); err != nil {
+		defer os.Remove(f.Name())
+		if err := f.Close(); err != nil {
 			return err
 		}
+		tokenFile = f.Name()
+	}
 
-		p.plog.Infof("Pushing branches for %s", repoRules.DestinationRepository)
+	push := func(t *publishTarget, phase string, env ...string) error {
+		if p.config.GithubAppID != 0 {
+			token, err := writeToken(tokenFile, p.tokenSource)
+			if err != nil {
+				return err
+			}
+			p.redactor.add(token)
+		}
+		start := time.Now()
+		cmd := exec.Command(p.config.BasePublishScriptPath+"/push.sh", tokenFile, t.branch)
+		cmd.Dir = t.dir
+		cmd.Env = append(os.Environ(),
+			"PUBLISHER_BOT_NETRC_MACHINE="+pushAuth.Machine,
+			"PUBLISHER_BOT_NETRC_LOGIN="+pushAuth.Login,
+			"PUBLISHER_BOT_PUSH_PHASE="+phase,
+		)
+		cmd.Env = append(cmd.Env, env...)
+		err := t.log.Run(cmd)
+		t.duration += time.Since(start)
+		return err
+	}
+	failed := func(t *publishTarget, result string, err error) {
+		p.report.update(t.repo, t.branch, func(br *BranchReport) {
+			br.DurationSeconds += t.duration.Seconds()
+			br.Push = result
+			br.Error = err.Error()
+		})
+	}
+	// isolated records the failure of t with the given push result and returns
+	// true if failures are isolated to the repository and its dependents.
+	isolated := func(t *publishTarget, result string, err error) bool {
+		failed(t, result, err)
+		if p.failures == nil {
+			return false
+		}
+		t.log.Errorf("Failed to publish %s/%s: %v", t.repo, t.branch, err)
+		p.failures.fail(t.repo, t.branch, PhasePublish, err)
+		return true
+	}
+
+	var targets []*publishTarget
+	for _, repoRules := range p.reposRules.Rules {
+		if repoRules.Skip || p.failures.isFailed(repoRules.DestinationRepository) {
+			continue
+		}
+		dstDir := filepath.Join(p.baseRepoPath, repoRules.DestinationRepository, "")
 		for i := range repoRules.Branches {
 			branchRule := repoRules.Branches[i]
 			if p.skippedBranch(branchRule.Source.Branch) {
 				continue
 			}
-
-			cmd := exec.Command(p.config.BasePublishScriptPath+"/push.sh", p.config.TokenFile, branchRule.Name)
-			if err := p.plog.Run(cmd); err != nil {
+			if _, ok := newUpstreamHeads[branchRule.Source.Branch]; !ok {
+				return fmt.Errorf("no upstream branch %q found", branchRule.Source.Branch)
+			}
+			oldHead, err := gitRevParse(dstDir, "refs/remotes/origin/"+branchRule.Name)
+			if err != nil {
+				return err
+			}
+			newHead, err := gitRevParse(dstDir, "refs/heads/"+branchRule.Name)
+			if err != nil {
 				return err
 			}
+			targets = append(targets, &publishTarget{
+				repo:         repoRules.DestinationRepository,
+				branch:       branchRule.Name,
+				sourceBranch: branchRule.Source.Branch,
+				dir:          dstDir,
+				oldHead:      oldHead,
+				newHead:      newHead,
+				log:          p.plog.with(logFields{Repo: repoRules.DestinationRepository, Branch: branchRule.Name, Phase: logPhasePush}),
+			})
+		}
+	}
 
-			upstreamBranchHead, ok := newUpstreamHeads[branchRule.Source.Branch]
-			if !ok {
-				return fmt.Errorf("no upstream branch %q found", branchRule.Source.Branch)
+	p.plog.Infof("Staging %d branches", len(targets))
+	for _, t := range targets {
+		if p.failures.isFailed(t.repo) {
+			continue
+		}
+		if err := push(t, "stage"); err != nil {
+			if isolated(t, ResultFailed, err) {
+				continue
 			}
-			if err := os.WriteFile(
-				path.Join(
-					path.Dir(dstDir),
-					publishedFileName(repoRules.DestinationRepository, branchRule.Name),
-				),
-				[]byte(upstreamBranchHead.String()),
-				0o644,
-			); err != nil {
+			return fmt.Errorf("failed to stage branch %s of %s: %w", t.branch, t.repo, err)
+		}
+	}
+
+	for _, t := range targets {
+		if p.failures.isFailed(t.repo) {
+			continue
+		}
+		t.log.Infof("Promoting branch %s of %s", t.branch, t.repo)
+		if err := push(t, "promote"); err != nil {
+			// dependents come later and are not promoted either
+			if isolated(t, ResultFailed, err) {
+				continue
+			}
+			err = fmt.Errorf("failed to promote branch %s of %s: %w", t.branch, t.repo, err)
+			if rollbackErr := p.rollback(targets, push); rollbackErr != nil {
+				return fmt.Errorf("%w; rollback failed: %w", err, rollbackErr)
+			}
+			return err
+		}
+		t.promoted = true
+	}
+
+	// promotedOnly records that t stays promoted without its tags being pushed.
+	promotedOnly := func(t *publishTarget) {
+		p.report.update(t.repo, t.branch, func(br *BranchReport) {
+			br.DurationSeconds += t.duration.Seconds()
+			br.Push = ResultPromoted
+		})
+	}
+	tagsPending := map[string]bool{}
+	for i, t := range targets {
+		if p.failures.isFailed(t.repo) {
+			if t.promoted {
+				promotedOnly(t)
+			}
+			continue
+		}
+		if err := push(t, "tags"); err != nil {
+			if isolated(t, ResultTagsFailed, err) {
+				continue
+			}
+			for _, rest := range targets[i+1:] {
+				if rest.promoted {
+					promotedOnly(rest)
+				}
+			}
+			return fmt.Errorf("failed to push tags of branch %s of %s: %w", t.branch, t.repo, err)
+		}
+
+		var commits, tags int
+		p.report.update(t.repo, t.branch, func(br *BranchReport) {
+			br.DurationSeconds += t.duration.Seconds()
+			br.Push = ResultPushed
+			commits, tags = br.CherryPicked, len(br.TagsCreated)
+		})
+		p.metrics.observePush(t.repo, t.branch, t.duration, commits, tags)
+		if tags > 0 && p.reposRules.TagPolicy.MaxTagsPerRun > 0 {
+			// more tags might be left for the next runs
+			tagsPending[t.repo] = true
+		}
+
+		if err := os.WriteFile(
+			path.Join(p.baseRepoPath, publishedFileName(t.repo, t.branch)),
+			[]byte(newUpstreamHeads[t.sourceBranch].String()),
+			0o644,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, repoRules := range p.reposRules.Rules {
+		if repoRules.Skip || p.failures.isFailed(repoRules.DestinationRepository) {
+			continue
+		}
+		digest, err := p.rulesDigest(&repoRules)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path.Join(p.baseRepoPath, rulesDigestFileName(repoRules.DestinationRepository)), []byte(digest), 0o644); err != nil {
+			return err
+		}
+		tagsDigestFile := path.Join(p.baseRepoPath, tagsDigestFileName(repoRules.DestinationRepository))
+		if p.tagsDigest == "" || tagsPending[repoRules.DestinationRepository] {
+			if err := os.Remove(tagsDigestFile); err != nil && !os.IsNotExist(err) {
 				return err
 			}
+			continue
+		}
+		if err := os.WriteFile(tagsDigestFile, []byte(p.tagsDigest), 0o644); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// rollback resets the promoted branches to their old heads, in reverse order.
+func (p *PublisherMunger) rollback(targets []*publishTarget, push func(*publishTarget, string, ...string) error) error {
+	var errs []error
+	for i := len(targets) - 1; i >= 0; i-- {
+		t := targets[i]
+		if !t.promoted || t.oldHead == t.newHead {
+			continue
+		}
+		t.log.Infof("Rolling back branch %s of %s to %q", t.branch, t.repo, t.oldHead)
+		if err := push(t, "rollback", "PUBLISHER_BOT_OLD_HEAD="+t.oldHead); err != nil {
+			errs = append(errs, fmt.Errorf("branch %s of %s: %w", t.branch, t.repo, err))
+			continue
+		}
+		p.report.update(t.repo, t.branch, func(br *BranchReport) {
+			br.DurationSeconds += t.duration.Seconds()
+			br.Push = ResultRolledBack
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// gitRevParse returns the commit of the given ref in the repository at dir, or
+// the empty string if the ref does not exist.
+func gitRevParse(dir, ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s in %s: %w", ref, dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// writeToken writes a current token of ts to the given file, and returns it.
+func writeToken(fileName string, ts oauth2.TokenSource) (string, error) {
+	tok, err := ts.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get push token: %w", err)
+	}
+	return tok.AccessToken, os.WriteFile(fileName, []byte(tok.AccessToken), 0o600)
+}
+
 func publishedFileName(repo, branch string) string {
 	branch = strings.ReplaceAll(branch, "/", "_")
 	return fmt.Sprintf("published-%s-%s", repo, branch)
 }
 
+// SetTriggers sets the causes of the next run.
+func (p *PublisherMunger) SetTriggers(ts []*RunTrigger) {
+	p.triggers = ts
+}
+
+// SourceBranches returns the source branches of the rules loaded in the last
+// run, or nil if no rules were loaded.
+func (p *PublisherMunger) SourceBranches() map[string]bool {
+	return p.sourceBranches
+}
+
+// SetLogStream sets the broadcaster the logs of the runs are copied to.
+func (p *PublisherMunger) SetLogStream(b *logBroadcaster) {
+	p.logStream = b
+}
+
+// Report returns the report of the current or last run.
+func (p *PublisherMunger) Report() *RunReport {
+	return p.report.snapshot()
+}
+
 // Run constructs the repos and pushes them. It returns logs and the last master hash.
 func (p *PublisherMunger) Run() (logs, masterHead string, err error) {
+	// secrets are read again for every run, as the files might be updated
+	p.redactor = newRedactor(configSecrets(p.config)...)
+	if p.tokenSource != nil {
+		if tok, err := p.tokenSource.Token(); err == nil {
+			p.redactor.add(tok.AccessToken)
+		}
+	}
+
+	p.logStream.start()
+	defer p.logStream.finish()
+
 	buf := bytes.NewBuffer(nil)
-	if p.plog, err = newPublisherLog(buf, path.Join(p.baseRepoPath, "run.log")); err != nil {
+	var stream io.Writer
+	if p.logStream != nil {
+		stream = p.logStream
+	}
+	if p.plog, err = newPublisherLog(buf, stream, path.Join(p.baseRepoPath, "run.log"), p.config.LogFormat, p.redactor); err != nil {
 		return "", "", err
 	}
 
+	start := time.Now()
+	p.report = newRunReporter(p.redactor)
+	p.report.updateRun(func(report *RunReport) {
+		report.Triggers = p.triggers
+	})
+	for _, t := range p.triggers {
+		p.plog.Infof("Run triggered by %s", t)
+	}
+	defer func() {
+		p.metrics.observeRun(time.Since(start), err)
+		if err := p.report.finish(p.reportFileName(), masterHead, err); err != nil {
+			glog.Errorf("Failed to write run report: %v", err)
+		}
+	}()
+
 	newUpstreamHeads, err := p.updateSourceRepo()
 	if err != nil {
-		p.plog.Errorf("%v", err)
+		p.plog.with(logFields{Phase: logPhaseFetch}).Errorf("%v", err)
 		p.plog.Flush()
 		return p.plog.Logs(), "", err
 	}
 
 	if err := p.updateRules(); err != nil { // this comes after the source update because we might fetch the rules from there.
+		p.plog.with(logFields{Phase: logPhaseRules}).Errorf("%v", err)
+		p.plog.Flush()
+		return p.plog.Logs(), "", err
+	}
+	// before repositories are skipped as unchanged
+	p.sourceBranches = sourceBranches(&p.reposRules)
+
+	skipped, err := p.skipUnchanged(newUpstreamHeads)
+	if err != nil {
 		p.plog.Errorf("%v", err)
 		p.plog.Flush()
 		return p.plog.Logs(), "", err
 	}
+	p.report.updateRun(func(report *RunReport) {
+		report.SkippedRepositories = skipped
+	})
 
-	if err := p.construct(); err != nil {
+	checkpoint, err := loadCheckpoint(filepath.Join(p.baseRepoPath, checkpointFileName), upstreamKey(newUpstreamHeads))
+	if err != nil {
 		p.plog.Errorf("%v", err)
 		p.plog.Flush()
 		return p.plog.Logs(), "", err
 	}
+	p.checkpoint = checkpoint
+	if p.config.IsolateFailures {
+		p.failures = newFailureTracker(&p.reposRules)
+	}
+
+	if err := p.construct(); err != nil {
+		p.plog.with(logFields{Phase: logPhaseConstruct}).Errorf("%v", err)
+		p.plog.Flush()
+		return p.plog.Logs(), "", err
+	}
 
 	if err := p.publish(newUpstreamHeads); err != nil {
+		p.plog.with(logFields{Phase: logPhasePush}).Errorf("%v", err)
+		p.plog.Flush()
+		return p.plog.Logs(), "", err
+	}
+
+	if err := p.failures.err(); err != nil {
+		p.report.updateRun(func(report *RunReport) {
+			report.Failures = p.failures.list()
+		})
 		p.plog.Errorf("%v", err)
 		p.plog.Flush()
 		return p.plog.Logs(), "", err
 	}
 
+	if err := p.checkpoint.clear(); err != nil {
+		p.plog.Errorf("Failed to remove checkpoint: %v", err)
+	}
+
 	if h, ok := newUpstreamHeads["master"]; ok {
 		masterHead = h.String()
 	}
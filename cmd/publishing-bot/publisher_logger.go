@@ -22,6 +22,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -35,12 +37,59 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Log formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Phases of a run, as logged in JSON format.
+const (
+	logPhaseFetch     = "fetch"
+	logPhaseRules     = "rules"
+	logPhaseConstruct = "construct"
+	logPhaseSmoke     = "smoke"
+	logPhasePush      = "push"
+)
+
+// logFields are the context of log records in JSON format.
+type logFields struct {
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Phase  string `json:"phase,omitempty"`
+}
+
+// logRecord is one line of the logs in JSON format.
+type logRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	logFields
+	Command string `json:"command,omitempty"`
+	// Stream is stdout or stderr for the output of a command.
+	Stream   string `json:"stream,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Message  string `json:"message"`
+}
+
 type plog struct {
-	combinedBufAndFile io.Writer
-	buf                *bytes.Buffer
+	// text receives the logs in text format, i.e. the buffer, and the log file
+	// in text format.
+	text io.Writer
+	// json receives the logs as JSON lines in JSON format, i.e. the log file
+	// and stdout. It is nil in text format.
+	json io.Writer
+	buf  *bytes.Buffer
+	// redactor scrubs secrets from everything logged, before it reaches glog,
+	// the buffer or the log file.
+	redactor *redactor
+	// fields are added to the records in JSON format.
+	fields logFields
 }
 
-func newPublisherLog(buf *bytes.Buffer, logFileName string) (*plog, error) {
+// newPublisherLog returns a logger writing text to buf and stream, and text or
+// JSON lines to the log file depending on format. The buffer is always text,
+// as it ends up in issue comments. stream may be nil.
+func newPublisherLog(buf *bytes.Buffer, stream io.Writer, logFileName, format string, r *redactor) (*plog, error) {
 	logFile := &lumberjack.Logger{
 		Filename: logFileName,
 		MaxAge:   7,
@@ -49,47 +98,109 @@ func newPublisherLog(buf *bytes.Buffer, logFileName string) (*plog, error) {
 		return nil, err
 	}
 
-	return &plog{newSyncWriter(muxWriter{buf, logFile}), buf}, nil
+	text := muxWriter{buf}
+	if stream != nil {
+		text = append(text, stream)
+	}
+
+	switch format {
+	case "", LogFormatText:
+		return &plog{text: newSyncWriter(append(text, logFile)), buf: buf, redactor: r}, nil
+	case LogFormatJSON:
+		return &plog{
+			text:     newSyncWriter(text),
+			json:     newSyncWriter(muxWriter{logFile, os.Stdout}),
+			buf:      buf,
+			redactor: r,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be %s or %s", format, LogFormatText, LogFormatJSON)
+	}
 }
 
-func (p *plog) write(s string) {
-	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
-	p.combinedBufAndFile.Write([]byte("[" + time.Now().Format(time.RFC822) + "]: "))
+// with returns a logger adding the non-empty fields to the records in JSON
+// format. It shares the writers with p.
+func (p *plog) with(fields logFields) *plog {
+	c := *p
+	if fields.Repo != "" {
+		c.fields.Repo = fields.Repo
+	}
+	if fields.Branch != "" {
+		c.fields.Branch = fields.Branch
+	}
+	if fields.Phase != "" {
+		c.fields.Phase = fields.Phase
+	}
+	return &c
+}
 
+func (p *plog) write(level, s string) {
 	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
-	p.combinedBufAndFile.Write([]byte(s))
+	p.text.Write([]byte("[" + time.Now().Format(time.RFC822) + "]: " + s + "\n"))
 
+	p.writeJSON(logRecord{Level: level, Message: s})
+}
+
+// writeJSON writes the record in JSON format, and does nothing in text format.
+func (p *plog) writeJSON(rec logRecord) {
+	if p.json == nil {
+		return
+	}
+	rec.Timestamp = time.Now().UTC()
+	rec.logFields = p.fields
+	bs, err := json.Marshal(rec)
+	if err != nil {
+		glog.Errorf("Failed to marshal log record: %v", err)
+		return
+	}
 	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
-	p.combinedBufAndFile.Write([]byte("\n"))
+	p.json.Write(append(bs, '\n'))
 }
 
 func (p *plog) Errorf(format string, args ...interface{}) {
-	s := prefixFollowingLines("    ", fmt.Sprintf(format, args...))
+	s := p.redactor.redact(prefixFollowingLines("    ", fmt.Sprintf(format, args...)))
 	glog.ErrorDepth(1, s)
-	p.write(s)
+	p.write("error", s)
 }
 
 func (p *plog) Infof(format string, args ...interface{}) {
-	s := prefixFollowingLines("    ", fmt.Sprintf(format, args...))
+	s := p.redactor.redact(prefixFollowingLines("    ", fmt.Sprintf(format, args...)))
 	glog.InfoDepth(1, s)
-	p.write(s)
+	p.write("info", s)
 }
 
 func (p *plog) Fatalf(format string, args ...interface{}) {
-	s := prefixFollowingLines("    ", fmt.Sprintf(format, args...))
+	s := p.redactor.redact(prefixFollowingLines("    ", fmt.Sprintf(format, args...)))
 	glog.FatalDepth(1, s)
-	p.write(s)
+	p.write("fatal", s)
 }
 
 func (p *plog) Run(c *exec.Cmd) error {
-	p.Infof("%s", cmdStr(c))
+	command := p.redactor.redact(cmdStr(c))
+	glog.InfoDepth(1, command)
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	p.text.Write([]byte("[" + time.Now().Format(time.RFC822) + "]: " + command + "\n"))
+	p.writeJSON(logRecord{Level: "info", Command: command, Message: command})
 
 	errBuf := &bytes.Buffer{}
 
-	stdoutLineWriter := newLineWriter(muxWriter{p.combinedBufAndFile, os.Stdout})
-	stderrLineWriter := newLineWriter(muxWriter{p.combinedBufAndFile, errBuf})
+	stdout := io.Writer(p.text)
+	if p.json == nil {
+		stdout = muxWriter{p.text, os.Stdout}
+	}
+	stdoutLineWriter := newLineWriter(redactWriter{p.redactor, stdout})
+	stderrLineWriter := newLineWriter(redactWriter{p.redactor, muxWriter{p.text, errBuf}})
 	c.Stdout = indentwriter.New(stdoutLineWriter, 1)
 	c.Stderr = indentwriter.New(stderrLineWriter, 1)
+	lineWriters := []lineWriter{stdoutLineWriter, stderrLineWriter}
+	if p.json != nil {
+		// the same output again as tagged records, without indentation
+		jsonStdoutLineWriter := newLineWriter(redactWriter{p.redactor, streamWriter{p, command, "stdout"}})
+		jsonStderrLineWriter := newLineWriter(redactWriter{p.redactor, streamWriter{p, command, "stderr"}})
+		c.Stdout = io.MultiWriter(c.Stdout, jsonStdoutLineWriter)
+		c.Stderr = io.MultiWriter(c.Stderr, jsonStderrLineWriter)
+		lineWriters = append(lineWriters, jsonStdoutLineWriter, jsonStderrLineWriter)
+	}
 
 	err := c.Start()
 	if err != nil {
@@ -97,14 +208,45 @@ func (p *plog) Run(c *exec.Cmd) error {
 		return err
 	}
 	err = c.Wait()
+	for _, lw := range lineWriters {
+		lw.Flush()
+	}
+
+	exitCode := 0
 	if err != nil {
-		p.Errorf("%s\n%s", err.Error(), errBuf.String())
+		exitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		s := p.redactor.redact(prefixFollowingLines("    ", fmt.Sprintf("%s\n%s", err.Error(), errBuf.String())))
+		glog.ErrorDepth(1, s)
+		//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+		p.text.Write([]byte("[" + time.Now().Format(time.RFC822) + "]: " + s + "\n"))
+		p.writeJSON(logRecord{Level: "error", Command: command, ExitCode: &exitCode, Message: s})
+	} else {
+		p.writeJSON(logRecord{Level: "info", Command: command, ExitCode: &exitCode, Message: "exit code 0"})
 	}
-	stdoutLineWriter.Flush()
-	stderrLineWriter.Flush()
 	return err
 }
 
+// streamWriter writes lines of command output as records in JSON format.
+type streamWriter struct {
+	p       *plog
+	command string
+	stream  string
+}
+
+func (sw streamWriter) Write(b []byte) (int, error) {
+	sw.p.writeJSON(logRecord{
+		Level:   "info",
+		Command: sw.command,
+		Stream:  sw.stream,
+		Message: strings.TrimRight(string(b), "\n"),
+	})
+	return len(b), nil
+}
+
 func (p *plog) Logs() string {
 	return p.buf.String()
 }
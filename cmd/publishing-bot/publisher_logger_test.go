@@ -17,7 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -69,3 +75,57 @@ func TestLogLineWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestPlogJSON(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "run.log")
+	buf := &bytes.Buffer{}
+	l, err := newPublisherLog(buf, nil, logFile, LogFormatJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := l.with(logFields{Repo: "api", Branch: "master", Phase: logPhaseConstruct})
+	log.Infof("constructing")
+	//nolint:errcheck // the exit code is checked in the log
+	log.with(logFields{Phase: logPhaseSmoke}).Run(exec.Command("bash", "-c", "echo out; echo err >&2; exit 3"))
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []logRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records, got %+v", records)
+	}
+
+	if r := records[0]; r.Level != "info" || r.Message != "constructing" || r.Phase != logPhaseConstruct || r.Repo != "api" || r.Branch != "master" {
+		t.Errorf("unexpected message record: %+v", r)
+	}
+	if r := records[1]; r.Command != "bash -c \"echo out; echo err >&2; exit 3\"" || r.Phase != logPhaseSmoke || r.Repo != "api" {
+		t.Errorf("unexpected command record: %+v", r)
+	}
+	streams := map[string]string{}
+	for _, r := range records[2:4] {
+		streams[r.Stream] = r.Message
+	}
+	if streams["stdout"] != "out" || streams["stderr"] != "err" {
+		t.Errorf("unexpected output records: %+v", records[2:4])
+	}
+	if r := records[4]; r.Level != "error" || r.ExitCode == nil || *r.ExitCode != 3 {
+		t.Errorf("unexpected exit record: %+v", r)
+	}
+
+	// the buffer for issue comments stays text
+	if logs := buf.String(); !strings.Contains(logs, "]: constructing\n") || !strings.Contains(logs, "\tout\n") || strings.Contains(logs, "{") {
+		t.Errorf("expected text logs in buffer, got:\n%s", logs)
+	}
+}
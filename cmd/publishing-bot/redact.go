@@ -0,0 +1,193 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+const redacted = "[REDACTED]"
+
+// minSecretLength is the length below which configured secrets are not
+// redacted, such that e.g. a one-character file does not garble the logs.
+const minSecretLength = 6
+
+var credentialPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// GitHub personal access, OAuth, user-to-server, server-to-server and
+	// refresh tokens
+	{regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`), redacted},
+	// passwords in URLs, e.g. https://x-access-token:<token>@github.com
+	{regexp.MustCompile(`(://[^/\s:@]+):[^/\s@]+@`), "${1}:" + redacted + "@"},
+}
+
+// redactor replaces secrets and credential-like strings. It is safe for
+// concurrent use. A nil redactor only replaces the credential patterns.
+type redactor struct {
+	lock    sync.RWMutex
+	secrets []string
+}
+
+func newRedactor(secrets ...string) *redactor {
+	r := &redactor{}
+	r.add(secrets...)
+	return r
+}
+
+// add registers more secrets, e.g. a refreshed token.
+func (r *redactor) add(secrets ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if len(s) < minSecretLength {
+			continue
+		}
+		found := false
+		for _, x := range r.secrets {
+			if x == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// longest first, such that no prefix of a secret is left
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+func (r *redactor) redact(s string) string {
+	if r != nil {
+		r.lock.RLock()
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+		r.lock.RUnlock()
+	}
+	for _, p := range credentialPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// redactWriter redacts what is written to it. The written bytes must be whole
+// lines, or a secret split between two writes is not redacted.
+type redactWriter struct {
+	r      *redactor
+	writer io.Writer
+}
+
+func (rw redactWriter) Write(b []byte) (int, error) {
+	if _, err := rw.writer.Write([]byte(rw.r.redact(string(b)))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// configSecrets returns the secrets in the files of the config and in the
+// netrc file of the user.
+func configSecrets(cfg *config.Config) []string {
+	files := []string{cfg.TokenFile, cfg.WebhookSecretFile, cfg.GithubAppPrivateKeyFile}
+	for _, n := range cfg.Notifications {
+		files = append(files, n.URLFile, n.SMTPPasswordFile)
+	}
+
+	var secrets []string
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		bs, err := os.ReadFile(f)
+		if err != nil {
+			glog.Warningf("Failed to read %s for redaction: %v", f, err)
+			continue
+		}
+		// every line of e.g. a PEM key on its own, as they are logged on
+		// their own
+		for _, l := range strings.Split(string(bs), "\n") {
+			if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "-----") {
+				secrets = append(secrets, l)
+			}
+		}
+	}
+	for _, n := range cfg.Notifications {
+		if n.URLFile == "" && n.URL != "" {
+			// e.g. the token of a Slack webhook is in the URL path
+			secrets = append(secrets, n.URL)
+		}
+	}
+
+	netrc := os.Getenv("NETRC")
+	if netrc == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			netrc = filepath.Join(home, ".netrc")
+		}
+	}
+	if bs, err := os.ReadFile(netrc); err == nil {
+		secrets = append(secrets, netrcSecrets(string(bs))...)
+	}
+
+	return secrets
+}
+
+// netrcSecrets returns the passwords of a netrc file, and the logins of
+// entries without password, as those are tokens used as login.
+func netrcSecrets(content string) []string {
+	var secrets []string
+	var login string
+	hasPassword := false
+	flush := func() {
+		if login != "" && !hasPassword {
+			secrets = append(secrets, login)
+		}
+		login, hasPassword = "", false
+	}
+	fields := strings.Fields(content)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine", "default":
+			flush()
+		case "login":
+			if i+1 < len(fields) {
+				i++
+				login = fields[i]
+			}
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				secrets = append(secrets, fields[i])
+				hasPassword = true
+			}
+		}
+	}
+	flush()
+	return secrets
+}
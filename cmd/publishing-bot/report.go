@@ -0,0 +1,314 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const runReportFileName = "run-report.json"
+
+// Results of the smoke tests and the push of a branch.
+const (
+	ResultPassed  = "passed"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+	ResultPushed  = "pushed"
+	// ResultRolledBack is a branch reset to its old head after another
+	// branch failed to publish.
+	ResultRolledBack = "rolled-back"
+	// ResultTagsFailed is a branch promoted, but whose tags failed to push.
+	// Branches are not rolled back after promotion.
+	ResultTagsFailed = "tags-failed"
+	// ResultPromoted is a branch promoted, but whose tags were not pushed
+	// because the tags of another branch failed to push.
+	ResultPromoted = "promoted"
+)
+
+// RunReport describes one publishing run.
+type RunReport struct {
+	StartTime    time.Time  `json:"startTime"`
+	EndTime      *time.Time `json:"endTime,omitempty"`
+	Successful   bool       `json:"successful"`
+	Error        string     `json:"error,omitempty"`
+	UpstreamHash string     `json:"upstreamHash,omitempty"`
+	// Triggers are the causes of the run, if not started by the interval.
+	Triggers []*RunTrigger `json:"triggers,omitempty"`
+
+	// SkippedRepositories are the repositories skipped because they did not
+	// change, with the reason.
+	SkippedRepositories map[string]string   `json:"skippedRepositories,omitempty"`
+	Repositories        []*RepositoryReport `json:"repositories,omitempty"`
+
+	// Failures are the repositories not published because they or their
+	// dependencies failed, if failures are isolated.
+	Failures []RepositoryFailure `json:"failures,omitempty"`
+
+	// Plan is what would have been pushed, in dry-run mode.
+	Plan *Plan `json:"plan,omitempty"`
+}
+
+// RepositoryReport describes the branches of one destination repository
+// constructed in a run.
+type RepositoryReport struct {
+	Name     string          `json:"name"`
+	Branches []*BranchReport `json:"branches,omitempty"`
+}
+
+// BranchReport describes the construction and push of one destination branch.
+type BranchReport struct {
+	Name         string `json:"name"`
+	SourceBranch string `json:"sourceBranch"`
+	OldHead      string `json:"oldHead,omitempty"`
+	NewHead      string `json:"newHead,omitempty"`
+	// CherryPicked is the number of commits added on top of the old head.
+	CherryPicked int `json:"cherryPicked"`
+	// TagsCreated are the tags created by sync-tags, to be pushed with the branch.
+	TagsCreated []string `json:"tagsCreated,omitempty"`
+	// StaleTags are the tags whose source tag was deleted or moved, if tag
+	// reconciliation is enabled.
+	StaleTags []StaleTag `json:"staleTags,omitempty"`
+	// Resumed is true if the branch was constructed by an earlier attempt for
+	// the same upstream heads and rules, and not constructed again.
+	Resumed bool `json:"resumed,omitempty"`
+	// SmokeTest is one of passed, failed or skipped.
+	SmokeTest string `json:"smokeTest,omitempty"`
+	// Push is one of pushed, failed, rolled-back, tags-failed, promoted or
+	// skipped.
+	Push  string `json:"push,omitempty"`
+	Error string `json:"error,omitempty"`
+	// DurationSeconds is the time spent constructing and pushing the branch.
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// StaleTag is a destination tag whose source tag was deleted or moved.
+type StaleTag struct {
+	Tag       string `json:"tag"`
+	SourceTag string `json:"sourceTag"`
+	// Reason is deleted or retargeted.
+	Reason string `json:"reason"`
+	// Rewritten is true if the tag is deleted or recreated with the branch.
+	Rewritten bool `json:"rewritten,omitempty"`
+}
+
+// runReporter collects the report of a run. It is safe for concurrent use.
+type runReporter struct {
+	lock   sync.Mutex
+	report RunReport
+	// redactor scrubs secrets from the errors in snapshots. It may be nil.
+	redactor *redactor
+}
+
+func newRunReporter(r *redactor) *runReporter {
+	return &runReporter{report: RunReport{StartTime: time.Now()}, redactor: r}
+}
+
+// update calls f with the report of the given branch, creating it if necessary.
+func (r *runReporter) update(repo, branch string, f func(*BranchReport)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var rr *RepositoryReport
+	for _, x := range r.report.Repositories {
+		if x.Name == repo {
+			rr = x
+			break
+		}
+	}
+	if rr == nil {
+		rr = &RepositoryReport{Name: repo}
+		r.report.Repositories = append(r.report.Repositories, rr)
+	}
+
+	var br *BranchReport
+	for _, x := range rr.Branches {
+		if x.Name == branch {
+			br = x
+			break
+		}
+	}
+	if br == nil {
+		br = &BranchReport{Name: branch}
+		rr.Branches = append(rr.Branches, br)
+	}
+
+	f(br)
+}
+
+// updateRun calls f with the run report.
+func (r *runReporter) updateRun(f func(*RunReport)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f(&r.report)
+}
+
+// snapshot returns a deep copy of the report, with secrets redacted from the
+// errors.
+func (r *runReporter) snapshot() *RunReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	report := r.report
+	report.Error = r.redactor.redact(report.Error)
+	report.Failures = nil
+	for _, f := range r.report.Failures {
+		f.Error = r.redactor.redact(f.Error)
+		report.Failures = append(report.Failures, f)
+	}
+	report.Repositories = nil
+	for _, rr := range r.report.Repositories {
+		rrCopy := &RepositoryReport{Name: rr.Name}
+		for _, br := range rr.Branches {
+			brCopy := *br
+			brCopy.Error = r.redactor.redact(br.Error)
+			brCopy.TagsCreated = append([]string(nil), br.TagsCreated...)
+			brCopy.StaleTags = append([]StaleTag(nil), br.StaleTags...)
+			rrCopy.Branches = append(rrCopy.Branches, &brCopy)
+		}
+		report.Repositories = append(report.Repositories, rrCopy)
+	}
+	return &report
+}
+
+// finish completes the report and writes it as JSON to the given file.
+func (r *runReporter) finish(fileName, upstreamHash string, err error) error {
+	r.updateRun(func(report *RunReport) {
+		now := time.Now()
+		report.EndTime = &now
+		report.UpstreamHash = upstreamHash
+		report.Successful = err == nil
+		if err != nil {
+			report.Error = err.Error()
+		}
+	})
+
+	bs, err := json.MarshalIndent(r.snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := fileName + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fileName)
+}
+
+// loadRunReport reads a report written by finish. It returns nil if the file
+// does not exist.
+func loadRunReport(fileName string) (*RunReport, error) {
+	bs, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var report RunReport
+	if err := json.Unmarshal(bs, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", fileName, err)
+	}
+	return &report, nil
+}
+
+// pushTagsFileName returns the name of the script sync-tags appends the tag
+// pushes of the given branch to.
+func pushTagsFileName(repo, branch string) string {
+	branch = strings.ReplaceAll(branch, "/", "_")
+	return fmt.Sprintf("push-tags-%s-%s.sh", repo, branch)
+}
+
+// pushScriptTags returns the tags pushed by the given push-tags script.
+func pushScriptTags(fileName string) ([]string, error) {
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tags []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "git" || fields[1] != "push" {
+			continue
+		}
+		for _, field := range fields[2:] {
+			if strings.HasPrefix(field, "refs/tags/") {
+				tags = append(tags, strings.TrimPrefix(field, "refs/tags/"))
+			}
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// pushScriptStaleTags returns the stale tags reported by sync-tags in the given
+// push-tags script, as comments like "# stale-tag <tag> <source-tag> <reason>
+// <rewritten>".
+func pushScriptStaleTags(fileName string) ([]StaleTag, error) {
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stale []StaleTag
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 6 || fields[0] != "#" || fields[1] != "stale-tag" {
+			continue
+		}
+		rewritten, err := strconv.ParseBool(fields[5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid stale tag %q in %s: %w", scanner.Text(), fileName, err)
+		}
+		stale = append(stale, StaleTag{Tag: fields[2], SourceTag: fields[3], Reason: fields[4], Rewritten: rewritten})
+	}
+	return stale, scanner.Err()
+}
+
+// countCommits returns the number of commits reachable from newHead, but not
+// from oldHead.
+func countCommits(dir, oldHead, newHead string) (int, error) {
+	revs := newHead
+	if oldHead != "" {
+		revs = oldHead + ".." + newHead
+	}
+	cmd := exec.Command("git", "rev-list", "--count", revs)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits %s: %w", revs, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+func (p *PublisherMunger) reportFileName() string {
+	return filepath.Join(p.baseRepoPath, runReportFileName)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunReporter(t *testing.T) {
+	r := newRunReporter(nil)
+	r.update("api", "master", func(br *BranchReport) {
+		br.SourceBranch = "master"
+		br.CherryPicked = 3
+		br.TagsCreated = []string{"v0.1.0"}
+	})
+	r.update("api", "release-1.0", func(br *BranchReport) {
+		br.Push = ResultSkipped
+	})
+	r.update("api", "master", func(br *BranchReport) {
+		br.Push = ResultPushed
+	})
+
+	fileName := filepath.Join(t.TempDir(), runReportFileName)
+	if err := r.finish(fileName, "abc", errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := loadRunReport(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Successful || report.Error != "boom" || report.UpstreamHash != "abc" || report.EndTime == nil {
+		t.Errorf("unexpected run result in %+v", report)
+	}
+	if len(report.Repositories) != 1 || len(report.Repositories[0].Branches) != 2 {
+		t.Fatalf("unexpected repositories %+v", report.Repositories)
+	}
+	want := BranchReport{Name: "master", SourceBranch: "master", CherryPicked: 3, TagsCreated: []string{"v0.1.0"}, Push: ResultPushed}
+	if got := *report.Repositories[0].Branches[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected branch report %+v, expected %+v", got, want)
+	}
+}
+
+func TestPushScriptTags(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), pushTagsFileName("api", "release/1.0"))
+	if err := os.WriteFile(fileName, []byte(`#!/bin/bash
+git push --atomic origin refs/tags/v0.1.0 refs/tags/kubernetes-1.1.0
+`), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := pushScriptTags(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v0.1.0", "kubernetes-1.1.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("unexpected tags %v, expected %v", tags, want)
+	}
+
+	if tags, err := pushScriptTags(filepath.Join(t.TempDir(), "missing")); err != nil || tags != nil {
+		t.Errorf("expected no tags for a missing script, got %v, %v", tags, err)
+	}
+}
+
+func TestPushScriptStaleTags(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), pushTagsFileName("api", "master"))
+	if err := os.WriteFile(fileName, []byte(`#!/bin/bash
+# stale-tag v0.2.0 v1.2.0 retargeted true
+# stale-tag v0.3.0 v1.3.0 deleted false
+git push --atomic --force origin refs/tags/v0.2.0
+`), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := pushScriptStaleTags(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StaleTag{
+		{Tag: "v0.2.0", SourceTag: "v1.2.0", Reason: "retargeted", Rewritten: true},
+		{Tag: "v0.3.0", SourceTag: "v1.3.0", Reason: "deleted"},
+	}
+	if !reflect.DeepEqual(stale, want) {
+		t.Errorf("unexpected stale tags %+v, expected %+v", stale, want)
+	}
+	if tags, err := pushScriptTags(fileName); err != nil || !reflect.DeepEqual(tags, []string{"v0.2.0"}) {
+		t.Errorf("expected recreated tag v0.2.0, got %v, %v", tags, err)
+	}
+}
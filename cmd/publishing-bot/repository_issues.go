@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
+)
+
+const defaultIssueLabel = "publishing-bot"
+
+// repositoryIssueTitle returns the stable title of the tracking issues in the
+// destination repositories.
+func repositoryIssueTitle(cfg *config.Config) string {
+	return fmt.Sprintf("Publishing from %s/%s failed", cfg.SourceOrg, cfg.SourceRepo)
+}
+
+// repositoryResults returns the repositories that failed in the run, with the
+// reason, and the repositories that are published, sorted. Repositories not
+// reached before the run aborted are in neither.
+func repositoryResults(report *RunReport) (failed map[string]string, published []string) {
+	failed = map[string]string{}
+	for _, f := range report.Failures {
+		if f.Dependency != "" {
+			failed[f.Repository] = fmt.Sprintf("dependency %s failed", f.Dependency)
+		} else {
+			failed[f.Repository] = fmt.Sprintf("%s failed: %s", f.Phase, f.Error)
+		}
+	}
+
+	for _, rr := range report.Repositories {
+		pushed := len(rr.Branches) > 0
+		for _, br := range rr.Branches {
+			if br.Error != "" {
+				if _, ok := failed[rr.Name]; !ok {
+					failed[rr.Name] = fmt.Sprintf("branch %s failed: %s", br.Name, br.Error)
+				}
+			}
+			pushed = pushed && br.Push == ResultPushed
+		}
+		if _, ok := failed[rr.Name]; !ok && pushed {
+			published = append(published, rr.Name)
+		}
+	}
+
+	// skipped repositories are unchanged since they were last published
+	for repo := range report.SkippedRepositories {
+		if _, ok := failed[repo]; !ok {
+			published = append(published, repo)
+		}
+	}
+	sort.Strings(published)
+
+	return failed, published
+}
+
+// ReportOnRepositoryIssues opens, or reopens and comments, the tracking issue of
+// every failed destination repository, and closes the issues of the published
+// ones.
+func ReportOnRepositoryIssues(prov provider.Provider, cfg *config.Config, report *RunReport, logs, token string) error {
+	ctx := context.Background()
+	title := repositoryIssueTitle(cfg)
+	label := cfg.IssueLabel
+	if label == "" {
+		label = defaultIssueLabel
+	}
+
+	// logs and report are redacted already. This is the last line of defense
+	// for the current token.
+	if token != "" {
+		logs = strings.ReplaceAll(logs, token, redacted)
+	}
+
+	failed, published := repositoryResults(report)
+	repos := make([]string, 0, len(failed))
+	for repo := range failed {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	var errs []error
+	for _, repo := range repos {
+		body := transfromLogToGithubFormat(logs, 50, fmt.Sprintf("Publishing %s failed: %s", repo, failed[repo]))
+		issue, err := prov.FindIssue(ctx, cfg.TargetOrg, repo, title, label)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if issue == nil {
+			glog.Infof("Opening tracking issue in %s/%s", cfg.TargetOrg, repo)
+			if _, err := prov.CreateIssue(ctx, cfg.TargetOrg, repo, title, label, body); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := prov.ReportOnIssue(ctx, cfg.TargetOrg, repo, issue.Number, body); err != nil {
+			errs = append(errs, fmt.Errorf("failed to report on issue #%d of %s/%s: %w", issue.Number, cfg.TargetOrg, repo, err))
+		}
+	}
+
+	for _, repo := range published {
+		issue, err := prov.FindIssue(ctx, cfg.TargetOrg, repo, title, label)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if issue == nil || !issue.Open {
+			continue
+		}
+		glog.Infof("Closing tracking issue #%d in %s/%s", issue.Number, cfg.TargetOrg, repo)
+		if err := prov.CloseIssue(ctx, cfg.TargetOrg, repo, issue.Number); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close issue #%d of %s/%s: %w", issue.Number, cfg.TargetOrg, repo, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
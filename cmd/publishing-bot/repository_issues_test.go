@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
+)
+
+// fakeIssueProvider records the issue calls. Issues are keyed by repository.
+type fakeIssueProvider struct {
+	provider.Provider
+
+	issues map[string]*provider.Issue
+	calls  []string
+}
+
+func (f *fakeIssueProvider) FindIssue(_ context.Context, org, repo, title, label string) (*provider.Issue, error) {
+	f.calls = append(f.calls, fmt.Sprintf("find %s/%s %q %s", org, repo, title, label))
+	return f.issues[repo], nil
+}
+
+func (f *fakeIssueProvider) CreateIssue(_ context.Context, org, repo, _, _, body string) (int, error) {
+	f.calls = append(f.calls, fmt.Sprintf("create %s/%s", org, repo))
+	if strings.Contains(body, "secret") {
+		return 0, fmt.Errorf("token leaked into body")
+	}
+	return 1, nil
+}
+
+func (f *fakeIssueProvider) ReportOnIssue(_ context.Context, org, repo string, issue int, _ string) error {
+	f.calls = append(f.calls, fmt.Sprintf("report %s/%s#%d", org, repo, issue))
+	return nil
+}
+
+func (f *fakeIssueProvider) CloseIssue(_ context.Context, org, repo string, issue int) error {
+	f.calls = append(f.calls, fmt.Sprintf("close %s/%s#%d", org, repo, issue))
+	return nil
+}
+
+func TestReportOnRepositoryIssues(t *testing.T) {
+	report := &RunReport{
+		SkippedRepositories: map[string]string{"unchanged": "no changes", "fixed": "no changes"},
+		Repositories: []*RepositoryReport{
+			{Name: "api", Branches: []*BranchReport{{Name: "master", Push: ResultPushed}}},
+			{Name: "client-go", Branches: []*BranchReport{{Name: "master", Push: ResultFailed, Error: "exit status 1"}}},
+			{Name: "apiserver", Branches: []*BranchReport{{Name: "master", Push: ResultSkipped}}},
+		},
+		Failures: []RepositoryFailure{
+			{Repository: "client-go", Branch: "master", Phase: PhasePublish, Error: "exit status 1"},
+			{Repository: "apiserver", Dependency: "client-go"},
+		},
+	}
+
+	failed, published := repositoryResults(report)
+	if want := []string{"apiserver", "client-go"}; !reflect.DeepEqual(sortedKeys(failed), want) {
+		t.Errorf("expected failed %v, got %v", want, failed)
+	}
+	if want := []string{"api", "fixed", "unchanged"}; !reflect.DeepEqual(published, want) {
+		t.Errorf("expected published %v, got %v", want, published)
+	}
+
+	prov := &fakeIssueProvider{issues: map[string]*provider.Issue{
+		"client-go": {Number: 7, Open: false},
+		"api":       {Number: 3, Open: true},
+		"unchanged": {Number: 4, Open: false},
+	}}
+	cfg := &config.Config{SourceOrg: "kubernetes", SourceRepo: "kubernetes", TargetOrg: "k8s"}
+	if err := ReportOnRepositoryIssues(prov, cfg, report, "pushing with secret failed", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	title := `"Publishing from kubernetes/kubernetes failed"`
+	want := []string{
+		"find k8s/apiserver " + title + " publishing-bot",
+		"create k8s/apiserver",
+		"find k8s/client-go " + title + " publishing-bot",
+		"report k8s/client-go#7",
+		"find k8s/api " + title + " publishing-bot",
+		"close k8s/api#3",
+		"find k8s/fixed " + title + " publishing-bot",
+		"find k8s/unchanged " + title + " publishing-bot",
+	}
+	if !reflect.DeepEqual(prov.calls, want) {
+		t.Errorf("unexpected calls:\n%q\nexpected:\n%q", prov.calls, want)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
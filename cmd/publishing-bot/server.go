@@ -18,22 +18,41 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+	"k8s.io/publishing-bot/cmd/publishing-bot/provider"
 )
 
 type Server struct {
-	Issue   int
-	RunChan chan bool
+	Issue    int
+	Provider provider.Provider
+	RunChan  chan bool
+	// WebhookSecret is the secret GitHub signs webhook payloads with. The
+	// webhook endpoint is disabled if empty.
+	WebhookSecret []byte
+	// History stores the last runs served at /runs. It may be nil.
+	History *runHistory
+	// LogStream streams the logs of the current run at /runs/current/log. It
+	// may be nil.
+	LogStream *logBroadcaster
 
 	mutex    sync.RWMutex
 	response HealthResponse
+	report   *RunReport
 	config   config.Config
+	// pendingTriggers are the causes of the next run, if triggered explicitly.
+	pendingTriggers []*RunTrigger
+	// sourceBranches are the source branches of the rules of the last run, or
+	// nil before the rules were loaded.
+	sourceBranches map[string]bool
 }
 
 type HealthResponse struct {
@@ -45,6 +64,9 @@ type HealthResponse struct {
 	LastFailureTime            *time.Time `json:"lastFailureTime,omitempty"`
 	LastSuccessfulUpstreamHash string     `json:"lastSuccessfulUpstreamHash,omitempty"`
 
+	// SkippedRepositories are the repositories skipped in the last run, with the reason.
+	SkippedRepositories map[string]string `json:"skippedRepositories,omitempty"`
+
 	Issue string `json:"issue,omitempty"`
 }
 
@@ -65,27 +87,81 @@ func (h *Server) SetHealth(healthy bool, hash string) {
 	}
 }
 
+func (h *Server) SetReport(report *RunReport) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.report = report
+	h.response.SkippedRepositories = report.SkippedRepositories
+}
+
 func (h *Server) Run(port int) {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", h.healthzHandler)
-	mux.HandleFunc("/run", h.runHandler)
 	addr := fmt.Sprintf("0.0.0.0:%d", port)
 	glog.Infof("Listening on %v", addr)
 	go func() {
-		err := http.ListenAndServe(addr, mux)
+		err := http.ListenAndServe(addr, h.handler())
 		glog.Fatalf("Failed ListenAndServer: %v", err)
 	}()
 }
 
-func (h *Server) runHandler(w http.ResponseWriter, _ *http.Request) {
+func (h *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.healthzHandler)
+	mux.HandleFunc("/run", h.runHandler)
+	mux.HandleFunc("/report", h.reportHandler)
+	mux.HandleFunc("/plan", h.planHandler)
+	mux.HandleFunc("/webhook", h.webhookHandler)
+	mux.HandleFunc("/runs", h.runsHandler)
+	mux.HandleFunc("/runs/{id}", h.runDetailsHandler)
+	mux.HandleFunc("/runs/{id}/log", h.runLogHandler)
+	mux.HandleFunc("/runs/current/log", h.currentLogHandler)
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// trigger adds a cause of the next run and starts it if the bot is waiting
+// for the next interval.
+func (h *Server) trigger(t *RunTrigger) error {
 	if h.RunChan == nil {
-		http.Error(w, "run channel is closed", http.StatusInternalServerError)
-		return
+		return errors.New("run channel is closed")
 	}
+
+	h.mutex.Lock()
+	h.pendingTriggers = append(h.pendingTriggers, t)
+	h.mutex.Unlock()
+
 	select {
 	case h.RunChan <- true:
 	default:
 	}
+	return nil
+}
+
+// TakeTriggers returns and forgets the causes of the next run. It returns nil
+// if the run was not triggered explicitly.
+func (h *Server) TakeTriggers() []*RunTrigger {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ts := h.pendingTriggers
+	h.pendingTriggers = nil
+	return ts
+}
+
+// SetSourceBranches sets the source branches pushes to which trigger a run.
+// Before they are set, every push to a branch triggers a run.
+func (h *Server) SetSourceBranches(branches map[string]bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sourceBranches = branches
+}
+
+func (h *Server) runHandler(w http.ResponseWriter, _ *http.Request) {
+	if err := h.trigger(&RunTrigger{Source: "manual", Time: time.Now()}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
 	w.Write([]byte("OK"))
@@ -94,10 +170,10 @@ func (h *Server) runHandler(w http.ResponseWriter, _ *http.Request) {
 func (h *Server) healthzHandler(w http.ResponseWriter, _ *http.Request) {
 	h.mutex.RLock()
 	resp := h.response
-	if h.Issue != 0 {
+	if h.Issue != 0 && h.Provider != nil {
 		// We chose target org so the issue can be opened in different org than
 		// a source repository.
-		resp.Issue = fmt.Sprintf("https://%s/%s/%s/issues/%d", h.config.GithubHost, h.config.TargetOrg, h.config.SourceRepo, h.Issue)
+		resp.Issue = h.Provider.IssueURL(h.config.TargetOrg, h.config.SourceRepo, h.Issue)
 	}
 	h.mutex.RUnlock()
 
@@ -110,3 +186,233 @@ func (h *Server) healthzHandler(w http.ResponseWriter, _ *http.Request) {
 	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
 	w.Write(bytes)
 }
+
+func (h *Server) reportHandler(w http.ResponseWriter, _ *http.Request) {
+	h.mutex.RLock()
+	report := h.report
+	h.mutex.RUnlock()
+
+	if report == nil {
+		http.Error(w, "no run finished yet", http.StatusNotFound)
+		return
+	}
+
+	bytes, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	w.Write(bytes)
+}
+
+// planHandler serves the plan of the last dry run as text, or as JSON with
+// ?format=json.
+func (h *Server) planHandler(w http.ResponseWriter, r *http.Request) {
+	h.mutex.RLock()
+	report := h.report
+	h.mutex.RUnlock()
+
+	if report == nil || report.Plan == nil {
+		http.Error(w, "no dry run finished yet", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") != "json" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+		w.Write([]byte(report.Plan.String()))
+		return
+	}
+
+	bytes, err := json.MarshalIndent(report.Plan, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	w.Write(bytes)
+}
+
+var runsTemplate = template.Must(template.New("runs").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Publishing runs</title></head>
+<body>
+<h1>Publishing runs</h1>
+<p><a href="/runs/current/log">Follow the current run</a></p>
+<table>
+<tr><th>Run</th><th>Started</th><th>Result</th><th>Trigger</th><th></th></tr>
+{{- range .}}
+<tr>
+<td><a href="/runs/{{.ID}}">{{.ID}}</a></td>
+<td>{{.StartTime}}</td>
+<td>{{if .Successful}}successful{{else}}failed: {{.Error}}{{end}}</td>
+<td>{{range $i, $t := .Triggers}}{{if $i}}, {{end}}{{$t}}{{else}}interval{{end}}</td>
+<td><a href="/runs/{{.ID}}/log">log</a></td>
+</tr>
+{{- else}}
+<tr><td colspan="5">No runs yet.</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+var runTemplate = template.Must(template.New("run").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Publishing run {{.ID}}</title></head>
+<body>
+<h1>Publishing run {{.ID}}</h1>
+{{- with .Report}}
+<p>Started {{.StartTime.UTC.Format "2006-01-02 15:04:05 MST"}}{{with .EndTime}}, finished {{.UTC.Format "2006-01-02 15:04:05 MST"}}{{end}}{{with .Triggers}}, triggered by {{range $i, $t := .}}{{if $i}}, {{end}}{{$t}}{{end}}{{end}}.</p>
+<p>{{if .Successful}}Successful{{else}}Failed: {{.Error}}{{end}}{{with .UpstreamHash}} at upstream {{.}}{{end}}.</p>
+{{- with .Failures}}
+<h2>Failed repositories</h2>
+<ul>
+{{- range .}}
+<li>{{.Repository}}: {{if .Dependency}}dependency {{.Dependency}} failed{{else}}{{.Phase}}{{with .Branch}} of branch {{.}}{{end}} failed: {{.Error}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with .Repositories}}
+<h2>Branches</h2>
+<table>
+<tr><th>Repository</th><th>Branch</th><th>Source</th><th>Old head</th><th>New head</th><th>Cherry-picked</th><th>Tags</th><th>Smoke test</th><th>Push</th><th>Error</th></tr>
+{{- range $repo := .}}{{range .Branches}}
+<tr><td>{{$repo.Name}}</td><td>{{.Name}}</td><td>{{.SourceBranch}}</td><td>{{.OldHead}}</td><td>{{.NewHead}}</td><td>{{.CherryPicked}}</td><td>{{range $i, $t := .TagsCreated}}{{if $i}}, {{end}}{{$t}}{{end}}</td><td>{{.SmokeTest}}</td><td>{{.Push}}</td><td>{{.Error}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- with .SkippedRepositories}}
+<h2>Skipped repositories</h2>
+<ul>
+{{- range $repo, $reason := .}}
+<li>{{$repo}}: {{$reason}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+<p><a href="/runs/{{.ID}}/log">Log</a> | <a href="/runs">All runs</a></p>
+</body>
+</html>
+`))
+
+// runsHandler serves the summaries of the last runs as HTML, or as JSON with
+// ?format=json.
+func (h *Server) runsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.History == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	runs, err := h.History.list()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	serveHTMLOrJSON(w, r, runsTemplate, runs, runs)
+}
+
+// runDetailsHandler serves the report of one run as HTML, or as JSON with
+// ?format=json.
+func (h *Server) runDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.History == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	id := r.PathValue("id")
+	report, err := h.History.report(id)
+	if errors.Is(err, errRunNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := struct {
+		ID     string
+		Report *RunReport
+	}{id, report}
+	serveHTMLOrJSON(w, r, runTemplate, data, report)
+}
+
+// runLogHandler serves the log of one run as text.
+func (h *Server) runLogHandler(w http.ResponseWriter, r *http.Request) {
+	if h.History == nil {
+		http.Error(w, "run history is disabled", http.StatusNotFound)
+		return
+	}
+	logs, err := h.History.logs(r.PathValue("id"))
+	if errors.Is(err, errRunNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	w.Write(logs)
+}
+
+// currentLogHandler streams the logs of the run in progress as chunked text,
+// starting with the most recent logs written so far. The response ends with the run.
+func (h *Server) currentLogHandler(w http.ResponseWriter, r *http.Request) {
+	backlog, ch, cancel := h.LogStream.subscribe()
+	defer cancel()
+	if ch == nil {
+		http.Error(w, "no run in progress", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	// browsers render the text as it arrives only without sniffing
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flush := func() {}
+	if f, ok := w.(http.Flusher); ok {
+		flush = f.Flush
+	}
+
+	if _, err := w.Write(backlog); err != nil {
+		return
+	}
+	flush()
+	for {
+		select {
+		case b, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(b); err != nil {
+				return
+			}
+			flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveHTMLOrJSON renders data with the template, or serves v as JSON with
+// ?format=json.
+func serveHTMLOrJSON(w http.ResponseWriter, r *http.Request, t *template.Template, data, v interface{}) {
+	if r.URL.Query().Get("format") == "json" {
+		bytes, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+		w.Write(bytes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.Execute(w, data); err != nil {
+		glog.Errorf("Failed to render %s: %v", t.Name(), err)
+	}
+}
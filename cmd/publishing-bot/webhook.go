@@ -0,0 +1,176 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+// GitHub limits webhook payloads to 25 MB.
+const maxWebhookPayload = 25 << 20
+
+// RunTrigger describes what caused a run.
+type RunTrigger struct {
+	// Source is "webhook" or "manual".
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	// Ref, After and Delivery describe the push event of a webhook trigger.
+	Ref      string `json:"ref,omitempty"`
+	After    string `json:"after,omitempty"`
+	Delivery string `json:"delivery,omitempty"`
+}
+
+func (t *RunTrigger) String() string {
+	if t.Source == "webhook" {
+		return fmt.Sprintf("push of %s to %s (delivery %s)", t.After, t.Ref, t.Delivery)
+	}
+	return t.Source
+}
+
+// pushEvent is the part of a GitHub push event payload we care about.
+type pushEvent struct {
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Deleted    bool   `json:"deleted"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// validSignature checks the X-Hub-Signature-256 header value against the
+// HMAC of the payload.
+func validSignature(secret, payload []byte, signature string) bool {
+	hexSig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// sourceBranches returns the source branches of the non-skipped rules.
+func sourceBranches(rules *config.RepositoryRules) map[string]bool {
+	skipped := map[string]bool{}
+	for _, b := range rules.SkippedSourceBranches {
+		skipped[b] = true
+	}
+	branches := map[string]bool{}
+	for _, r := range rules.Rules {
+		if r.Skip {
+			continue
+		}
+		for _, br := range r.Branches {
+			if !skipped[br.Source.Branch] {
+				branches[br.Source.Branch] = true
+			}
+		}
+	}
+	return branches
+}
+
+func (h *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	if len(h.WebhookSecret) == 0 {
+		http.Error(w, "webhook secret not configured", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validSignature(h.WebhookSecret, payload, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch event := r.Header.Get("X-GitHub-Event"); event {
+	case "ping":
+		//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+		w.Write([]byte("pong"))
+		return
+	case "push":
+	default:
+		glog.Infof("Ignoring webhook %q event", event)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var push pushEvent
+	if err := json.Unmarshal(payload, &push); err != nil {
+		http.Error(w, fmt.Sprintf("invalid push event: %v", err), http.StatusBadRequest)
+		return
+	}
+	sourceRepo := fmt.Sprintf("%s/%s", h.config.SourceOrg, h.config.SourceRepo)
+	if !strings.EqualFold(push.Repository.FullName, sourceRepo) {
+		glog.Infof("Ignoring push to %s", push.Repository.FullName)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	branch, isBranch := strings.CutPrefix(push.Ref, "refs/heads/")
+	if !isBranch || push.Deleted {
+		glog.Infof("Ignoring push to %s", push.Ref)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// the rules are not loaded again for every push, but taken from the last run
+	h.mutex.RLock()
+	branches := h.sourceBranches
+	h.mutex.RUnlock()
+	if branches != nil && !branches[branch] {
+		glog.Infof("Ignoring push to %s, not a source branch", push.Ref)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	trigger := &RunTrigger{
+		Source:   "webhook",
+		Time:     time.Now(),
+		Ref:      push.Ref,
+		After:    push.After,
+		Delivery: r.Header.Get("X-GitHub-Delivery"),
+	}
+	glog.Infof("Triggering run for %s", trigger)
+	if err := h.trigger(trigger); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	//nolint:errcheck  // TODO(lint): Should we be checking errors here?
+	w.Write([]byte("OK"))
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"k8s.io/publishing-bot/cmd/publishing-bot/config"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte(`
+skip-source-branches:
+- release-0.9
+rules:
+- destination: api
+  branches:
+  - name: master
+    source:
+      branch: master
+  - name: release-0.9
+    source:
+      branch: release-0.9
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := config.LoadRules(rulesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("s3cret")
+
+	tests := []struct {
+		name        string
+		event       string
+		payload     string
+		signature   string
+		wantCode    int
+		wantTrigger bool
+	}{
+		{"source branch", "push", pushPayload("kcp-dev/kcp", "refs/heads/master"), "", http.StatusOK, true},
+		{"invalid signature", "push", pushPayload("kcp-dev/kcp", "refs/heads/master"), "sha256=0000", http.StatusUnauthorized, false},
+		{"missing signature", "push", pushPayload("kcp-dev/kcp", "refs/heads/master"), "-", http.StatusUnauthorized, false},
+		{"unknown branch", "push", pushPayload("kcp-dev/kcp", "refs/heads/feature"), "", http.StatusAccepted, false},
+		{"skipped branch", "push", pushPayload("kcp-dev/kcp", "refs/heads/release-0.9"), "", http.StatusAccepted, false},
+		{"tag", "push", pushPayload("kcp-dev/kcp", "refs/tags/v1.0.0"), "", http.StatusAccepted, false},
+		{"other repo", "push", pushPayload("kcp-dev/other", "refs/heads/master"), "", http.StatusAccepted, false},
+		{"ping", "ping", `{}`, "", http.StatusOK, false},
+		{"other event", "issues", `{}`, "", http.StatusAccepted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{
+				RunChan:        make(chan bool, 1),
+				WebhookSecret:  secret,
+				config:         config.Config{SourceOrg: "kcp-dev", SourceRepo: "kcp"},
+				sourceBranches: sourceBranches(rules),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.payload))
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "42")
+			switch tt.signature {
+			case "":
+				req.Header.Set("X-Hub-Signature-256", signPayload(secret, tt.payload))
+			case "-":
+			default:
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			w := httptest.NewRecorder()
+			server.webhookHandler(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusAccepted && w.Body.Len() != 0 {
+				t.Errorf("expected empty body for ignored event, got %q", w.Body.String())
+			}
+			triggers := server.TakeTriggers()
+			if got := len(triggers) == 1; got != tt.wantTrigger {
+				t.Fatalf("expected trigger %v, got %v", tt.wantTrigger, triggers)
+			}
+			if got := len(server.RunChan) == 1; got != tt.wantTrigger {
+				t.Errorf("expected run to be started %v, got %v", tt.wantTrigger, got)
+			}
+			if tt.wantTrigger && (triggers[0].After != "0123abcd" || triggers[0].Ref != "refs/heads/master" || triggers[0].Delivery != "42") {
+				t.Errorf("unexpected trigger %+v", triggers[0])
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerMultipleDeliveries(t *testing.T) {
+	secret := []byte("s3cret")
+	server := &Server{
+		RunChan:       make(chan bool, 1),
+		WebhookSecret: secret,
+		config:        config.Config{SourceOrg: "kcp-dev", SourceRepo: "kcp"},
+	}
+
+	// without rules from a run, pushes to any branch trigger
+	for i, ref := range []string{"refs/heads/master", "refs/heads/feature"} {
+		payload := pushPayload("kcp-dev/kcp", ref)
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", strconv.Itoa(i))
+		req.Header.Set("X-Hub-Signature-256", signPayload(secret, payload))
+		w := httptest.NewRecorder()
+		server.webhookHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	triggers := server.TakeTriggers()
+	if len(triggers) != 2 || triggers[0].Delivery != "0" || triggers[1].Delivery != "1" {
+		t.Fatalf("expected both deliveries as triggers, got %v", triggers)
+	}
+	if triggers := server.TakeTriggers(); triggers != nil {
+		t.Errorf("expected no triggers after taking them, got %v", triggers)
+	}
+}
+
+func TestWebhookHandlerDisabled(t *testing.T) {
+	server := &Server{RunChan: make(chan bool, 1)}
+	w := httptest.NewRecorder()
+	server.webhookHandler(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("{}")))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d without a secret, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func signPayload(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func pushPayload(repo, ref string) string {
+	return `{"ref":"` + ref + `","after":"0123abcd","repository":{"full_name":"` + repo + `"}}`
+}
@@ -22,9 +22,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
-	"regexp"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -36,7 +38,6 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
 	"github.com/golang/glog"
-	"github.com/lithammer/dedent"
 	"k8s.io/publishing-bot/pkg/cache"
 	"k8s.io/publishing-bot/pkg/git"
 )
@@ -54,6 +55,13 @@ Usage: %s --source-remote <remote> --source-branch <source-branch>
           [--origin-branch <branch>]
           [--prefix <tag-prefix>]
           [--push-script <file-path>]
+          [--mapping-store-dir <dir>]
+          [--source-host <host>] [--source-org <org>] [--source-repo <repo>]
+          [--tag-message-template <template>]
+          [--tag-mappings <json>]
+          [--min-tag-date <date>] [--max-tags <n>] [--only-newer-tags]
+          [--lightweight-tags]
+          [--reconcile-tags [--rewrite-tags]]
 `, os.Args[0])
 	flag.PrintDefaults()
 }
@@ -61,8 +69,24 @@ Usage: %s --source-remote <remote> --source-branch <source-branch>
 const (
 	rfc2822        = "Mon Jan 02 15:04:05 -0700 2006"
 	refsTagsPrefix = "refs/tags/"
+
+	defaultTagMessageTemplate = `{{.SourceRepo}} release {{.SourceTag}}
+{{if .SourceOrg}}
+Based on https://{{.SourceHost}}/{{.SourceOrg}}/{{.SourceRepo}}/releases/tag/{{.SourceTag}}
+{{end}}`
 )
 
+// tagMessageData are the fields of the tag message template.
+type tagMessageData struct {
+	SourceTag         string
+	DestinationTag    string
+	SourceHost        string
+	SourceOrg         string
+	SourceRepo        string
+	SourceCommit      string
+	DestinationCommit string
+}
+
 var publishingBot = object.Signature{
 	Name:  os.Getenv("GIT_COMMITTER_NAME"),
 	Email: os.Getenv("GIT_COMMITTER_EMAIL"),
@@ -83,6 +107,18 @@ func main() {
 	publishSemverTags := flag.Bool("publish-semver-tags", false, "publish vX.Y.Z tag at destination repo for vX.Y.Z tag at the source repo")
 	skipNonSemverTags := flag.Bool("skip-non-semver-tags", false, "skip non-semver tags at the source repo")
 	semverTagsBase := flag.String("semver-tags-base", "v0", "the value to use as vX in vX.Y.Z published at the destination repo")
+	mappingStoreDir := flag.String("mapping-store-dir", "", "a directory with the source->dest hash mappings stored by collapsed-kube-commit-mapper, to only compute them incrementally")
+	sourceHost := flag.String("source-host", "github.com", "the host of the source repo, available as {{.SourceHost}} in the tag message template")
+	sourceOrg := flag.String("source-org", "", "the source repo org, available as {{.SourceOrg}} in the tag message template")
+	sourceRepo := flag.String("source-repo", "", "the source repo name, available as {{.SourceRepo}} in the tag message template")
+	tagMappingsJSON := flag.String("tag-mappings", "", "a JSON list of tag mappings with source regular expression, destination template and prereleases filter (include, exclude or only); replaces --prefix, --publish-v0-semver, --publish-semver-tags, --semver-tags-base and --skip-non-semver-tags")
+	minTagDate := flag.String("min-tag-date", "", "skip source tags older than this date (YYYY-MM-DD or RFC 3339)")
+	maxTags := flag.Int("max-tags", 0, "the maximum number of source tags to publish, oldest first (0 for no limit)")
+	onlyNewerTags := flag.Bool("only-newer-tags", false, "skip source tags not newer than the last published source tag")
+	lightweightTags := flag.Bool("lightweight-tags", false, "also publish lightweight source tags, dated by the committer date of the tagged commit")
+	reconcile := flag.Bool("reconcile-tags", false, "report tags created before whose source tag was deleted or moved (requires --mapping-store-dir)")
+	rewriteTags := flag.Bool("rewrite-tags", false, "delete tags reported by --reconcile-tags whose source tag was deleted, and recreate those whose source tag moved, by force-pushing them in the push script")
+	tagMessageTemplate := flag.String("tag-message-template", "", "a text/template of the message of created tags with the fields {{.SourceTag}}, {{.DestinationTag}}, {{.SourceHost}}, {{.SourceOrg}}, {{.SourceRepo}}, {{.SourceCommit}} and {{.DestinationCommit}} (defaults to a release message linking the source tag)")
 
 	flag.Usage = Usage
 	flag.Parse()
@@ -95,10 +131,49 @@ func main() {
 		glog.Fatalf("source-branch cannot be empty")
 	}
 
+	if *reconcile && *mappingStoreDir == "" {
+		glog.Fatalf("reconcile-tags requires mapping-store-dir")
+	}
+
+	if *rewriteTags && !*reconcile {
+		glog.Fatalf("rewrite-tags requires reconcile-tags")
+	}
+
 	if *publishV0Semver && *publishSemverTags {
 		glog.Fatalf("only one of publish-v0-semver and publish-semver-tags can be true")
 	}
 
+	if *tagMessageTemplate == "" {
+		*tagMessageTemplate = defaultTagMessageTemplate
+	}
+	tagMessageTpl, err := template.New("tag-message-template").Parse(*tagMessageTemplate)
+	if err != nil {
+		glog.Fatalf("Failed to parse tag message template: %v", err)
+	}
+
+	prefixOptions := prefixTagOptions{
+		prefix:            *prefix,
+		skipNonSemverTags: *skipNonSemverTags,
+		publishV0Semver:   *publishV0Semver,
+		publishSemverTags: *publishSemverTags,
+		semverTagsBase:    *semverTagsBase,
+	}
+	policy := tagPolicy{maxTags: *maxTags, onlyNewer: *onlyNewerTags}
+	if *minTagDate != "" {
+		policy.minDate, err = parseTagDate(*minTagDate)
+		if err != nil {
+			glog.Fatal(err)
+		}
+	}
+
+	var mappings []tagMapping
+	if *tagMappingsJSON != "" {
+		mappings, err = parseTagMappings(*tagMappingsJSON)
+		if err != nil {
+			glog.Fatal(err)
+		}
+	}
+
 	var dependentRepos []string
 	if *dependencies != "" {
 		for _, pair := range strings.Split(*dependencies, ",") {
@@ -131,9 +206,21 @@ func main() {
 	if err != nil {
 		glog.Fatalf("Failed to open upstream branch %s head: %v", *sourceBranch, err)
 	}
-	srcFirstParents, err := git.FirstParentList(r, srcHead)
-	if err != nil {
-		glog.Fatalf("Failed to get upstream branch %s first-parent list: %v", *sourceBranch, err)
+
+	// srcMainline is the first-parent list of the upstream branch, newest first
+	var srcMainline []plumbing.Hash
+	var sourceCommitsToDstCommits map[plumbing.Hash]plumbing.Hash
+	if *mappingStoreDir != "" {
+		// the mapping is cheap to update incrementally, hence do it eagerly
+		sourceCommitsToDstCommits, srcMainline = storedMapping(r, *mappingStoreDir, *commitMsgTag, localBranch, srcHead)
+	} else {
+		srcFirstParents, err := git.FirstParentList(r, srcHead)
+		if err != nil {
+			glog.Fatalf("Failed to get upstream branch %s first-parent list: %v", *sourceBranch, err)
+		}
+		for _, kc := range srcFirstParents {
+			srcMainline = append(srcMainline, kc.Hash)
+		}
 	}
 
 	// delete remote tags locally
@@ -172,127 +259,147 @@ func main() {
 
 	// filter tags by source branch
 	srcFirstParentCommits := map[string]struct{}{}
-	for _, kc := range srcFirstParents {
-		srcFirstParentCommits[kc.Hash.String()] = struct{}{}
+	for _, kh := range srcMainline {
+		srcFirstParentCommits[kh.String()] = struct{}{}
 	}
+	srcTagTargets := map[string]plumbing.Hash{}
 	for name, kh := range srcTagCommits {
-		// ignore non-annotated tags
-		tag, err := r.TagObject(kh)
+		// ignore non-annotated tags, unless lightweight tags are enabled
+		target, _, ok, err := resolveSourceTag(r, kh, *lightweightTags)
 		if err != nil {
+			glog.Fatalf("Failed to resolve tag %s: %v", name, err)
+		}
+		if !ok {
 			delete(srcTagCommits, name)
 			continue
 		}
+		srcTagTargets[name] = target
 
 		// delete tag not on the source branch
-		if _, ok := srcFirstParentCommits[tag.Target.String()]; !ok {
+		if _, ok := srcFirstParentCommits[target.String()]; !ok {
 			delete(srcTagCommits, name)
 		}
 	}
 
-	var sourceCommitsToDstCommits map[plumbing.Hash]plumbing.Hash
-
-	mappingFilesWritten := map[string]bool{}
-
-	// create or update tags from srcTagCommits as local tags with the given prefix
-	createdTags := []string{}
-	for name, kh := range srcTagCommits {
-		if *skipNonSemverTags {
-			if _, semverErr := semver.Parse(strings.TrimPrefix(name, "v")); semverErr != nil {
-				continue
+	// load the sources of the tags created before, to reconcile them and to
+	// record new ones
+	var (
+		recordsFileName string
+		records         map[plumbing.Hash]tagRecord
+		originTags      = maps.Clone(bTagCommits)
+		createdRecords  = map[plumbing.Hash]bool{}
+	)
+	if *mappingStoreDir != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			glog.Fatalf("Failed to get current working directory: %v", err)
+		}
+		recordsFileName = tagRecordsFileName(*mappingStoreDir, filepath.Base(wd), localBranch)
+		if records, err = loadTagRecords(recordsFileName); err != nil {
+			glog.Fatalf("Failed to load tag records: %v", err)
+		}
+	}
+
+	// report recorded tags whose source tag was deleted or moved, and delete
+	// or recreate them if allowed
+	var (
+		staleTags   []staleTag
+		deletedTags []string
+		forcedTags  = map[string]bool{}
+	)
+	if *reconcile {
+		staleTags = reconcileTags(records, srcTagTargets, bTagCommits)
+		for _, st := range staleTags {
+			switch {
+			case !*rewriteTags:
+				fmt.Printf("Found %s. Not rewriting it without --rewrite-tags.\n", st)
+			case st.reason == staleTagDeleted:
+				fmt.Printf("Found %s. Deleting it.\n", st)
+				deletedTags = append(deletedTags, st.record.tag)
+				if tagExists(st.record.tag) {
+					if err := deleteTag(st.record.tag); err != nil {
+						glog.Fatalf("Failed to delete tag %s: %v", st.record.tag, err)
+					}
+				}
+			default:
+				fmt.Printf("Found %s. Recreating it.\n", st)
+				forcedTags[st.record.tag] = true
+				delete(bTagCommits, st.record.tag)
 			}
 		}
+	}
 
-		bName := name
-		if *prefix != "" {
-			bName = *prefix + name[1:] // remove the v
-		}
+	mappingFilesWritten := map[string]bool{}
 
-		var (
-			semverTag          = ""
-			publishSemverTag   = false
-			versionPrefixRegex = regexp.MustCompile(`^v\d+\.`)
-		)
-		// if we are publishing semver tags
-		if *publishV0Semver {
-			// and this is a valid v1... semver tag
-			if _, semverErr := semver.Parse(name[1:]); semverErr == nil && strings.HasPrefix(name, "v1.") {
-				publishSemverTag = true
-				semverTag = "v0." + strings.TrimPrefix(name, "v1.") // replace v1.x.y with v0.x.y
+	// collect the tags to create from srcTagCommits, with the given prefix or as
+	// mapped by the tag mappings, and the last published one
+	var (
+		candidates    []sourceTag
+		recreated     []sourceTag
+		lastPublished *sourceTag
+	)
+	for name, kh := range srcTagCommits {
+		var targets *tagTargets
+		if len(mappings) > 0 {
+			targets, err = mappedTargets(mappings, name)
+			if err != nil {
+				glog.Fatalf("Failed to map tag %s: %v", name, err)
 			}
+		} else {
+			targets = prefixOptions.targets(name)
 		}
-		if *publishSemverTags {
-			// and this is a valid semver tag
-			if _, semverErr := semver.Parse(strings.TrimPrefix(name, "v")); semverErr == nil {
-				publishSemverTag = true
-				semverTag = *semverTagsBase + "." + versionPrefixRegex.ReplaceAllString(name, "")
-			}
+		if targets == nil {
+			continue
 		}
 
-		// ignore non-annotated tags
-		tag, err := r.TagObject(kh)
+		target, when, ok, err := resolveSourceTag(r, kh, *lightweightTags)
 		if err != nil {
+			glog.Fatalf("Failed to resolve tag %s: %v", name, err)
+		}
+		if !ok {
 			continue
 		}
+		st := sourceTag{name: name, target: target, when: when, targets: targets}
 
-		// ignore old tags
-		if tag.Tagger.When.Before(time.Date(2017, 9, 1, 0, 0, 0, 0, time.UTC)) {
-			// TODO: Fix or remove
-			// fmt.Printf("Ignoring old tag origin/%s from %v\n", bName, tag.Tagger.When)
+		// skip if any of the tags exists at origin
+		if slices.ContainsFunc(targets.Tags, func(t string) bool {
+			_, ok := bTagCommits[t]
+			return ok
+		}) {
+			if lastPublished == nil || st.when.After(lastPublished.when) {
+				lastPublished = &st
+			}
+			// record tags published before they were recorded
+			for _, t := range targets.Tags {
+				if h, ok := bTagCommits[t]; ok && records != nil {
+					if _, recorded := records[h]; !recorded {
+						records[h] = tagRecord{tag: t, sourceTag: name, sourceCommit: target}
+					}
+				}
+			}
 			continue
 		}
-
-		// skip if either tag exists at origin
-		_, nonSemverTagAtOrigin := bTagCommits[bName]
-		_, semverTagAtOrigin := bTagCommits[semverTag]
-		if nonSemverTagAtOrigin || (publishSemverTag && semverTagAtOrigin) {
+		if slices.ContainsFunc(targets.Tags, func(t string) bool { return forcedTags[t] }) {
+			// recreated tags are not new, hence not subject to the policy
+			recreated = append(recreated, st)
 			continue
 		}
+		candidates = append(candidates, st)
+	}
 
-		// if any of the tag exists locally,
-		// delete the tags, clear the cache and recreate them
-		if tagExists(bName) {
-			commit, commitTime, err := taggedCommitHashAndTime(r, bName)
-			if err != nil {
-				glog.Fatalf("Failed to get tag %s: %v", bName, err)
-			}
-			rev := commit.String()
-
-			// The Go mod cache is built upon the target tag name,
-			// not the source tag name, so we use generated tag name here.
-			// Only if it doesn't exist for some reason, we fall back to
-			// the original tag name.
-			pseudoSemverString := strings.TrimPrefix(semverTag, "v")
-			if pseudoSemverString == "" {
-				pseudoSemverString = strings.TrimPrefix(name, "v")
-			}
-			pseudoSemver, err := semver.Parse(pseudoSemverString)
-			if err != nil {
-				glog.Fatalf("error parsing pseudo-version: %v", err)
-			}
-			// Both v0 and v1 use v0 in pseudo-version.
-			moduleMajor := pseudoSemver.Major
-			if moduleMajor == 1 {
-				moduleMajor = 0
-			}
-			pseudoVersion := fmt.Sprintf("v%d.0.0-%s-%s", moduleMajor, commitTime.UTC().Format("20060102150405"), rev[:12])
-
-			fmt.Printf("Clearing cache for local tag %s.\n", pseudoVersion)
-			if err := cleanCacheForTag(pseudoVersion); err != nil {
-				glog.Fatalf("Failed to clean go mod cache for %s: %v", pseudoVersion, err)
-			}
-
-			if err := deleteTag(bName); err != nil {
-				glog.Fatalf("Failed to delete tag %s: %v", bName, err)
-			}
-		}
+	// create or update the tags selected by the policy as local tags
+	createdTags, recreatedTags := []string{}, []string{}
+	for _, st := range append(recreated, policy.selectTags(candidates, lastPublished)...) {
+		name, targets := st.name, st.targets
 
-		if publishSemverTag && tagExists(semverTag) {
-			fmt.Printf("Clearing cache for local tag %s.\n", semverTag)
-			if err := cleanCacheForTag(semverTag); err != nil {
-				glog.Fatalf("Failed to clean go mod cache for %s: %v", semverTag, err)
+		// if any of the tags exists locally,
+		// delete the tags, clear the cache and recreate them
+		for _, t := range targets.Tags {
+			if !tagExists(t) {
+				continue
 			}
-			if err := deleteTag(semverTag); err != nil {
-				glog.Fatalf("Failed to delete tag %s: %v", semverTag, err)
+			if err := clearLocalTag(r, t, moduleVersion(name, targets)); err != nil {
+				glog.Fatalf("Failed to clear local tag %s: %v", t, err)
 			}
 		}
 
@@ -302,7 +409,7 @@ func main() {
 			if err != nil {
 				glog.Fatalf("Failed to open branch %s: %v", localBranch, err)
 			}
-			fmt.Printf("Computing mapping from kube commits to the local branch %q at %s because %q seems to be relevant.\n", localBranch, bRevision.String(), bName)
+			fmt.Printf("Computing mapping from kube commits to the local branch %q at %s because %q seems to be relevant.\n", localBranch, bRevision.String(), targets.SearchTag)
 			bHeadCommit, err := cache.CommitObject(r, *bRevision)
 			if err != nil {
 				glog.Fatalf("Failed to open branch %s head: %v", localBranch, err)
@@ -311,6 +418,10 @@ func main() {
 			if err != nil {
 				glog.Fatalf("Failed to get branch %s first-parent list: %v", localBranch, err)
 			}
+			srcFirstParents, err := git.FirstParentList(r, srcHead)
+			if err != nil {
+				glog.Fatalf("Failed to get upstream branch %s first-parent list: %v", *sourceBranch, err)
+			}
 			sourceCommitsToDstCommits, err = git.SourceCommitToDstCommits(r, *commitMsgTag, bFirstParents, srcFirstParents)
 			if err != nil {
 				glog.Fatalf("Failed to map upstream branch %s to HEAD: %v", *sourceBranch, err)
@@ -318,7 +429,7 @@ func main() {
 		}
 
 		// map kube commit to local branch
-		bh, found := sourceCommitsToDstCommits[tag.Target]
+		bh, found := sourceCommitsToDstCommits[st.target]
 		if !found {
 			// this means that the tag is not on the current source branch
 			continue
@@ -326,14 +437,14 @@ func main() {
 
 		// store source->dest hash mapping for debugging
 		if *mappingOutputFile != "" {
-			fname := mappingOutputFileName(*mappingOutputFile, localBranch, bName)
+			fname := mappingOutputFileName(*mappingOutputFile, localBranch, targets.SearchTag)
 			if !mappingFilesWritten[fname] {
 				fmt.Printf("Writing source->dest hash mapping to %q\n", fname)
 				f, err := os.Create(fname)
 				if err != nil {
 					glog.Fatal(err)
 				}
-				if err := writeKubeCommitMapping(f, sourceCommitsToDstCommits, srcFirstParents); err != nil {
+				if err := writeKubeCommitMapping(f, r, sourceCommitsToDstCommits, srcMainline); err != nil {
 					glog.Fatal(err)
 				}
 				f.Close()
@@ -351,56 +462,89 @@ func main() {
 			var changed bool
 			_, err = os.Stat("go.mod")
 			if err == nil {
-				changed = updateGoMod(bName, dependentRepos, publishSemverTag)
+				changed = updateGoMod(targets.SearchTag, dependentRepos, targets.Semver)
 			}
 
 			if changed {
-				if publishSemverTag {
-					bh = createCommitToFixDeps(wt, semverTag)
-				} else {
-					bh = createCommitToFixDeps(wt, bName)
-				}
+				bh = createCommitToFixDeps(wt, targets.Tags[0])
 			}
 		}
 
-		// create semver annotated tag
-		if publishSemverTag {
-			fmt.Printf("Tagging %v as %q.\n", bh, semverTag)
-			err = createAnnotatedTag(bh, semverTag, tag.Tagger.When, dedent.Dedent(fmt.Sprintf(`
-			kcp release %s
-
-			Based on https://github.com/kcp-dev/kcp/releases/tag/%s
-			`, name, name)))
+		// create annotated tags
+		for _, t := range targets.Tags {
+			fmt.Printf("Tagging %v as %q.\n", bh, t)
+			msg, err := tagMessage(tagMessageTpl, tagMessageData{
+				SourceTag:         name,
+				DestinationTag:    t,
+				SourceHost:        *sourceHost,
+				SourceOrg:         *sourceOrg,
+				SourceRepo:        *sourceRepo,
+				SourceCommit:      st.target.String(),
+				DestinationCommit: bh.String(),
+			})
 			if err != nil {
-				glog.Fatalf("Failed to create tag %q: %v", semverTag, err)
+				glog.Fatalf("Failed to render message of tag %q: %v", t, err)
+			}
+			if err := createAnnotatedTag(bh, t, st.when, msg); err != nil {
+				glog.Fatalf("Failed to create tag %q: %v", t, err)
+			}
+			if forcedTags[t] {
+				recreatedTags = append(recreatedTags, t)
+			} else {
+				createdTags = append(createdTags, t)
+			}
+
+			if records != nil {
+				h, err := tagHash(t)
+				if err != nil {
+					glog.Fatalf("Failed to get tag %q: %v", t, err)
+				}
+				records[h] = tagRecord{tag: t, sourceTag: name, sourceCommit: st.target}
+				createdRecords[h] = true
 			}
-			createdTags = append(createdTags, semverTag)
 		}
+	}
 
-		// create non-semver prefixed annotated tag
-		fmt.Printf("Tagging %v as %q.\n", bh, bName)
-		err = createAnnotatedTag(bh, bName, tag.Tagger.When, dedent.Dedent(fmt.Sprintf(`
-				kcp release %s
+	for _, st := range staleTags {
+		if forcedTags[st.record.tag] && !slices.Contains(recreatedTags, st.record.tag) {
+			fmt.Printf("Not recreating tag %s, source tag %s is not on the source branch %s anymore.\n", st.record.tag, st.record.sourceTag, *sourceBranch)
+		}
+	}
 
-				Based on https://github.com/kcp-dev/kcp/releases/tag/%s
-				`, name, name)))
-		if err != nil {
-			glog.Fatalf("Failed to create tag %q: %v", bName, err)
+	// store the records of the tags at origin and of those just created
+	if records != nil {
+		for h, rec := range records {
+			if originTags[rec.tag] != h && !createdRecords[h] {
+				delete(records, h)
+			}
+		}
+		if err := saveTagRecords(recordsFileName, records); err != nil {
+			glog.Fatalf("Failed to store tag records: %v", err)
 		}
-		createdTags = append(createdTags, bName)
 	}
 
 	// write push command for new tags
 	// we use git push --atomic because it treats
 	// any existing releases which have only non-semver tags as no-ops
 	// and both semver and non-semver tags are targeted in a single operation
-	if *pushScriptPath != "" && len(createdTags) > 0 {
+	var pushCommands []string
+	pushCommands = append(pushCommands, staleTagComments(staleTags, *rewriteTags, recreatedTags)...)
+	if len(deletedTags) > 0 {
+		pushCommands = append(pushCommands, "git push --atomic origin :"+refsTagsPrefix+strings.Join(deletedTags, " :"+refsTagsPrefix))
+	}
+	if len(recreatedTags) > 0 {
+		pushCommands = append(pushCommands, "git push --atomic --force origin "+refsTagsPrefix+strings.Join(recreatedTags, " "+refsTagsPrefix))
+	}
+	if len(createdTags) > 0 {
+		pushCommands = append(pushCommands, "git push --atomic origin "+refsTagsPrefix+strings.Join(createdTags, " "+refsTagsPrefix))
+	}
+	if *pushScriptPath != "" && len(pushCommands) > 0 {
 		pushScript, err := os.OpenFile(*pushScriptPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o755)
 		if err != nil {
 			glog.Fatalf("Failed to open push-script %q for appending: %v", *pushScriptPath, err)
 		}
 		defer pushScript.Close()
-		_, err = fmt.Fprintf(pushScript, "git push --atomic origin %s\n", refsTagsPrefix+strings.Join(createdTags, " "+refsTagsPrefix))
+		_, err = fmt.Fprintln(pushScript, strings.Join(pushCommands, "\n"))
 		if err != nil {
 			glog.Fatalf("Failed to write to push-script %q: %q", *pushScriptPath, err)
 		}
@@ -427,6 +571,32 @@ func remoteTags(r *gogit.Repository, remote string) (map[string]plumbing.Hash, e
 	return tagCommits, err
 }
 
+// resolveSourceTag returns the tagged commit and the date of the tag object or
+// commit kh. Annotated tags are dated by the tagger date. Lightweight tags are
+// resolved to their commit and dated by its committer date if lightweight is
+// true, and are skipped otherwise, i.e. false is returned.
+func resolveSourceTag(r *gogit.Repository, kh plumbing.Hash, lightweight bool) (plumbing.Hash, time.Time, bool, error) {
+	tag, err := r.TagObject(kh)
+	if err == nil {
+		return tag.Target, tag.Tagger.When, true, nil
+	}
+	if !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return plumbing.ZeroHash, time.Time{}, false, err
+	}
+	if !lightweight {
+		return plumbing.ZeroHash, time.Time{}, false, nil
+	}
+	commit, err := cache.CommitObject(r, kh)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// neither a tag nor a commit, e.g. a tagged tree
+		return plumbing.ZeroHash, time.Time{}, false, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, time.Time{}, false, err
+	}
+	return commit.Hash, commit.Committer.When, true, nil
+}
+
 func removeRemoteTags(r *gogit.Repository, remotes ...string) error {
 	refs, err := r.Storer.IterReferences()
 	if err != nil {
@@ -449,6 +619,54 @@ func removeRemoteTags(r *gogit.Repository, remotes ...string) error {
 	})
 }
 
+// moduleVersion returns the version the Go mod cache of the destination tags
+// is built upon. The cache is built upon the destination tag name, not the
+// source tag name, so we use the first semver destination tag here. Only if
+// there is none, we fall back to the source tag name.
+func moduleVersion(name string, targets *tagTargets) string {
+	for _, t := range targets.Tags {
+		if isSemverTag(t) {
+			return t
+		}
+	}
+	return name
+}
+
+// clearLocalTag deletes the local tag and clears the Go mod cache for it.
+// version is the module version of the tag, see moduleVersion.
+func clearLocalTag(r *gogit.Repository, tag, version string) error {
+	if isSemverTag(tag) {
+		fmt.Printf("Clearing cache for local tag %s.\n", tag)
+		if err := cleanCacheForTag(tag); err != nil {
+			return fmt.Errorf("failed to clean go mod cache for %s: %w", tag, err)
+		}
+		return deleteTag(tag)
+	}
+
+	commit, commitTime, err := taggedCommitHashAndTime(r, tag)
+	if err != nil {
+		return fmt.Errorf("failed to get tag %s: %w", tag, err)
+	}
+	rev := commit.String()
+
+	pseudoSemver, err := semver.Parse(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return fmt.Errorf("error parsing pseudo-version: %w", err)
+	}
+	// Both v0 and v1 use v0 in pseudo-version.
+	moduleMajor := pseudoSemver.Major
+	if moduleMajor == 1 {
+		moduleMajor = 0
+	}
+	pseudoVersion := fmt.Sprintf("v%d.0.0-%s-%s", moduleMajor, commitTime.UTC().Format("20060102150405"), rev[:12])
+
+	fmt.Printf("Clearing cache for local tag %s.\n", pseudoVersion)
+	if err := cleanCacheForTag(pseudoVersion); err != nil {
+		return fmt.Errorf("failed to clean go mod cache for %s: %w", pseudoVersion, err)
+	}
+	return deleteTag(tag)
+}
+
 func createAnnotatedTag(h plumbing.Hash, name string, date time.Time, message string) error {
 	setUsernameCmd := exec.Command("git", "config", "user.name", publishingBot.Name)
 	if err := setUsernameCmd.Run(); err != nil {
@@ -489,10 +707,13 @@ func fetchTags(r *gogit.Repository, remote string) error {
 	return err
 }
 
-func writeKubeCommitMapping(w io.Writer, m map[plumbing.Hash]plumbing.Hash, srcFirstParents []*object.Commit) error {
-	for _, kc := range srcFirstParents {
+func writeKubeCommitMapping(w io.Writer, r *gogit.Repository, m map[plumbing.Hash]plumbing.Hash, srcMainline []plumbing.Hash) error {
+	for _, kh := range srcMainline {
+		kc, err := cache.CommitObject(r, kh)
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", kh, err)
+		}
 		msg := strings.SplitN(kc.Message, "\n", 2)[0]
-		var err error
 		if dh, ok := m[kc.Hash]; ok {
 			_, err = fmt.Fprintf(w, "%s %s %s\n", kc.Hash, dh, msg)
 		} else {
@@ -506,6 +727,44 @@ func writeKubeCommitMapping(w io.Writer, m map[plumbing.Hash]plumbing.Hash, srcF
 	return nil
 }
 
+// storedMapping loads the source->dest hash mapping of the local branch
+// from the mapping store and maps the commits added since in memory. The store
+// is only written by collapsed-kube-commit-mapper. It returns the mapping and
+// the upstream mainline, newest first.
+func storedMapping(r *gogit.Repository, storeDir, commitMsgTag, localBranch string, srcHead *object.Commit) (map[plumbing.Hash]plumbing.Hash, []plumbing.Hash) {
+	wd, err := os.Getwd()
+	if err != nil {
+		glog.Fatalf("Failed to get current working directory: %v", err)
+	}
+	repo := filepath.Base(wd)
+
+	bRevision, err := r.ResolveRevision(plumbing.Revision(fmt.Sprintf("refs/heads/%s", localBranch)))
+	if err != nil {
+		glog.Fatalf("Failed to open branch %s: %v", localBranch, err)
+	}
+	bHeadCommit, err := cache.CommitObject(r, *bRevision)
+	if err != nil {
+		glog.Fatalf("Failed to open branch %s head: %v", localBranch, err)
+	}
+
+	store := git.NewMappingStore(storeDir)
+	m, err := store.Load(repo, localBranch)
+	if err != nil {
+		glog.Fatalf("Failed to load mapping of %s: %v", repo, err)
+	}
+	added, err := git.UpdateMapping(r, commitMsgTag, m, srcHead, bHeadCommit)
+	if err != nil {
+		glog.Fatalf("Failed to map upstream to the local branch %s: %v", localBranch, err)
+	}
+	fmt.Printf("Mapped %d upstream commits not in the stored mapping to the local branch %q.\n", added, localBranch)
+
+	mainline := make([]plumbing.Hash, 0, len(m.Mainline))
+	for i := len(m.Mainline) - 1; i >= 0; i-- {
+		mainline = append(mainline, m.Mainline[i])
+	}
+	return m.Commits, mainline
+}
+
 func mappingOutputFileName(fnameTpl, branch, tag string) string {
 	tpl, err := template.New("mapping-output-file").Parse(fnameTpl)
 	if err != nil {
@@ -525,6 +784,15 @@ func mappingOutputFileName(fnameTpl, branch, tag string) string {
 	return buf.String()
 }
 
+// tagMessage renders the message of a created tag.
+func tagMessage(tpl *template.Template, data tagMessageData) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 func checkoutBranchTagCommit(r *gogit.Repository, bh plumbing.Hash, dependentRepos []string) *gogit.Worktree {
 	fmt.Printf("Checking that dependencies point to the actual tags in %s.\n", strings.Join(dependentRepos, ", "))
 	wt, err := r.Worktree()
@@ -563,6 +831,17 @@ func createCommitToFixDeps(wt *gogit.Worktree, tag string) plumbing.Hash {
 	return bh
 }
 
+// tagHash returns the hash of the local tag, i.e. of the tag object for
+// annotated tags.
+func tagHash(tag string) (plumbing.Hash, error) {
+	cmd := exec.Command("git", "rev-parse", refsTagsPrefix+tag)
+	out, err := cmd.Output()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return plumbing.NewHash(strings.TrimSpace(string(out))), nil
+}
+
 func deleteTag(tag string) error {
 	cmd := exec.Command("git", "tag", "-d", tag)
 	cmd.Stdout = os.Stdout
@@ -16,7 +16,16 @@ limitations under the License.
 
 package main
 
-import "testing"
+import (
+	"testing"
+	"text/template"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
 
 func Test_mappingOutputFileName(t *testing.T) {
 	type args struct {
@@ -39,3 +48,112 @@ func Test_mappingOutputFileName(t *testing.T) {
 		})
 	}
 }
+
+func Test_tagMessage(t *testing.T) {
+	data := tagMessageData{
+		SourceTag:         "v1.2.3",
+		DestinationTag:    "v0.2.3",
+		SourceHost:        "github.com",
+		SourceOrg:         "kcp-dev",
+		SourceRepo:        "kcp",
+		SourceCommit:      "1111111111111111111111111111111111111111",
+		DestinationCommit: "2222222222222222222222222222222222222222",
+	}
+	tests := []struct {
+		name   string
+		tpl    string
+		modify func(d *tagMessageData)
+		want   string
+	}{
+		{"default", defaultTagMessageTemplate, nil, "kcp release v1.2.3\n\nBased on https://github.com/kcp-dev/kcp/releases/tag/v1.2.3\n"},
+		{"default with other host", defaultTagMessageTemplate, func(d *tagMessageData) { d.SourceHost = "github.example.com" }, "kcp release v1.2.3\n\nBased on https://github.example.com/kcp-dev/kcp/releases/tag/v1.2.3\n"},
+		{"default without org", defaultTagMessageTemplate, func(d *tagMessageData) { d.SourceOrg = "" }, "kcp release v1.2.3\n"},
+		{"tags", "{{.DestinationTag}} from {{.SourceTag}}", nil, "v0.2.3 from v1.2.3"},
+		{"commits", "{{.DestinationCommit}} from {{.SourceOrg}}/{{.SourceRepo}}@{{.SourceCommit}}", nil, "2222222222222222222222222222222222222222 from kcp-dev/kcp@1111111111111111111111111111111111111111"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := data
+			if tt.modify != nil {
+				tt.modify(&data)
+			}
+			got, err := tagMessage(template.Must(template.New("tag-message-template").Parse(tt.tpl)), data)
+			if err != nil {
+				t.Fatalf("tagMessage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("tagMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveSourceTag(t *testing.T) {
+	r, err := gogit.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tagged := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tree := r.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	th, err := r.Storer.SetEncodedObject(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := r.Storer.NewEncodedObject()
+	sig := object.Signature{Name: "bot", Email: "bot@example.com", When: committed}
+	if err := (&object.Commit{Author: sig, Committer: sig, Message: "initial", TreeHash: th}).Encode(commit); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := r.Storer.SetEncodedObject(commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotated, err := r.CreateTag("v1.0.0", ch, &gogit.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "bot", Email: "bot@example.com", When: tagged},
+		Message: "v1.0.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lightweight, err := r.CreateTag("v1.0.1", ch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		ref         *plumbing.Reference
+		lightweight bool
+		wantOK      bool
+		wantWhen    time.Time
+	}{
+		{"annotated", annotated, false, true, tagged},
+		{"lightweight skipped", lightweight, false, false, time.Time{}},
+		{"lightweight", lightweight, true, true, committed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, when, ok, err := resolveSourceTag(r, tt.ref.Hash(), tt.lightweight)
+			if err != nil {
+				t.Fatalf("resolveSourceTag() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("resolveSourceTag() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if target != ch {
+				t.Errorf("resolveSourceTag() target = %v, want %v", target, ch)
+			}
+			if !when.Equal(tt.wantWhen) {
+				t.Errorf("resolveSourceTag() when = %v, want %v", when, tt.wantWhen)
+			}
+		})
+	}
+}
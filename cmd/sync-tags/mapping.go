@@ -0,0 +1,225 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/blang/semver/v4"
+)
+
+// Prerelease filters of tag mappings.
+const (
+	prereleasesInclude = "include"
+	prereleasesExclude = "exclude"
+	prereleasesOnly    = "only"
+)
+
+// tagMappingSpec is a tag mapping as passed with --tag-mappings, the JSON form
+// of the tag-mappings of a repository rule.
+type tagMappingSpec struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Prereleases string `json:"prereleases,omitempty"`
+}
+
+// tagMapping maps source tags matching a regular expression to a destination
+// tag rendered from a template.
+type tagMapping struct {
+	source      *regexp.Regexp
+	destination *template.Template
+	prereleases string
+}
+
+// destinationTagData are the fields of the destination tag template.
+type destinationTagData struct {
+	// Tag is the source tag.
+	Tag string
+	// Match are the source tag and the submatches of the source regular
+	// expression.
+	Match []string
+	// Groups are the named submatches of the source regular expression.
+	Groups map[string]string
+}
+
+// parseTagMappings parses the JSON list of tag mappings.
+func parseTagMappings(s string) ([]tagMapping, error) {
+	var specs []tagMappingSpec
+	if err := json.Unmarshal([]byte(s), &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse tag mappings: %w", err)
+	}
+	mappings := make([]tagMapping, 0, len(specs))
+	for i, spec := range specs {
+		source, err := regexp.Compile(spec.Source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source of tag mapping %d: %w", i, err)
+		}
+		if spec.Destination == "" {
+			return nil, fmt.Errorf("empty destination of tag mapping %d", i)
+		}
+		destination, err := template.New("destination").Option("missingkey=error").Parse(spec.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination of tag mapping %d: %w", i, err)
+		}
+		switch spec.Prereleases {
+		case "":
+			spec.Prereleases = prereleasesInclude
+		case prereleasesInclude, prereleasesExclude, prereleasesOnly:
+		default:
+			return nil, fmt.Errorf("invalid prereleases %q of tag mapping %d, must be %s, %s or %s", spec.Prereleases, i, prereleasesInclude, prereleasesExclude, prereleasesOnly)
+		}
+		mappings = append(mappings, tagMapping{source: source, destination: destination, prereleases: spec.Prereleases})
+	}
+	return mappings, nil
+}
+
+// isPrerelease returns true if tag is a semver tag with a prerelease version,
+// e.g. v1.2.0-rc.1.
+func isPrerelease(tag string) bool {
+	v, err := semver.Parse(strings.TrimPrefix(tag, "v"))
+	return err == nil && len(v.Pre) > 0
+}
+
+// isSemverTag returns true if tag is a vX.Y.Z semver tag.
+func isSemverTag(tag string) bool {
+	if !strings.HasPrefix(tag, "v") {
+		return false
+	}
+	_, err := semver.Parse(tag[1:])
+	return err == nil
+}
+
+// destinationTag returns the destination tag of the source tag name, and false
+// if the mapping does not apply to it.
+func (m tagMapping) destinationTag(name string) (string, bool, error) {
+	match := m.source.FindStringSubmatch(name)
+	if match == nil {
+		return "", false, nil
+	}
+	switch pre := isPrerelease(name); {
+	case m.prereleases == prereleasesExclude && pre:
+		return "", false, nil
+	case m.prereleases == prereleasesOnly && !pre:
+		return "", false, nil
+	}
+
+	data := destinationTagData{Tag: name, Match: match, Groups: map[string]string{}}
+	for i, g := range m.source.SubexpNames() {
+		if g != "" {
+			data.Groups[g] = match[i]
+		}
+	}
+	var buf bytes.Buffer
+	if err := m.destination.Execute(&buf, data); err != nil {
+		return "", false, fmt.Errorf("failed to render destination tag for %q: %w", name, err)
+	}
+	tag := strings.TrimSpace(buf.String())
+	if tag == "" {
+		return "", false, nil
+	}
+	return tag, true, nil
+}
+
+// tagTargets are the destination tags of a source tag.
+type tagTargets struct {
+	// Tags are the destination tags, in the order they are created. The
+	// first one is used in the commit message when fixing dependencies.
+	Tags []string
+	// SearchTag is the tag the dependencies are looked up by when updating
+	// go.mod.
+	SearchTag string
+	// Semver is true if go.mod requires the semver tags of the dependencies.
+	Semver bool
+}
+
+// mappedTargets returns the destination tags of all mappings applying to the
+// source tag name, or nil if none applies.
+func mappedTargets(mappings []tagMapping, name string) (*tagTargets, error) {
+	var tags []string
+	seen := map[string]bool{}
+	for _, m := range mappings {
+		tag, ok, err := m.destinationTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return &tagTargets{Tags: tags, SearchTag: tags[0], Semver: isSemverTag(tags[0])}, nil
+}
+
+// prefixTagOptions configure the destination tags of source tags without tag
+// mappings.
+type prefixTagOptions struct {
+	prefix            string
+	skipNonSemverTags bool
+	publishV0Semver   bool
+	publishSemverTags bool
+	semverTagsBase    string
+}
+
+var versionPrefixRegex = regexp.MustCompile(`^v\d+\.`)
+
+// targets returns the prefixed tag and optionally the semver tag of the source
+// tag name, or nil if it is skipped.
+func (o prefixTagOptions) targets(name string) *tagTargets {
+	if o.skipNonSemverTags {
+		if _, semverErr := semver.Parse(strings.TrimPrefix(name, "v")); semverErr != nil {
+			return nil
+		}
+	}
+
+	bName := name
+	if o.prefix != "" {
+		bName = o.prefix + name[1:] // remove the v
+	}
+
+	var (
+		semverTag        = ""
+		publishSemverTag = false
+	)
+	// if we are publishing semver tags
+	if o.publishV0Semver {
+		// and this is a valid v1... semver tag
+		if _, semverErr := semver.Parse(name[1:]); semverErr == nil && strings.HasPrefix(name, "v1.") {
+			publishSemverTag = true
+			semverTag = "v0." + strings.TrimPrefix(name, "v1.") // replace v1.x.y with v0.x.y
+		}
+	}
+	if o.publishSemverTags {
+		// and this is a valid semver tag
+		if _, semverErr := semver.Parse(strings.TrimPrefix(name, "v")); semverErr == nil {
+			publishSemverTag = true
+			semverTag = o.semverTagsBase + "." + versionPrefixRegex.ReplaceAllString(name, "")
+		}
+	}
+
+	if publishSemverTag {
+		return &tagTargets{Tags: []string{semverTag, bName}, SearchTag: bName, Semver: true}
+	}
+	return &tagTargets{Tags: []string{bName}, SearchTag: bName}
+}
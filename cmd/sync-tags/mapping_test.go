@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_mappedTargets(t *testing.T) {
+	mappings, err := parseTagMappings(`[
+		{"source": "^v1\\.(\\d+\\.\\d+)$", "destination": "v0.{{index .Match 1}}", "prereleases": "exclude"},
+		{"source": "^v(?P<version>.*)$", "destination": "staging/foo/v{{.Groups.version}}"},
+		{"source": "^v.*-rc\\.\\d+$", "destination": "rc-{{.Tag}}", "prereleases": "only"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tag  string
+		want *tagTargets
+	}{
+		{"all matching", "v1.2.3", &tagTargets{Tags: []string{"v0.2.3", "staging/foo/v1.2.3"}, SearchTag: "v0.2.3", Semver: true}},
+		{"submodule only", "v2.0.0", &tagTargets{Tags: []string{"staging/foo/v2.0.0"}, SearchTag: "staging/foo/v2.0.0"}},
+		{"prerelease", "v1.2.0-rc.1", &tagTargets{Tags: []string{"staging/foo/v1.2.0-rc.1", "rc-v1.2.0-rc.1"}, SearchTag: "staging/foo/v1.2.0-rc.1"}},
+		{"not matching", "release-1.2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mappedTargets(mappings, tt.tag)
+			if err != nil {
+				t.Fatalf("mappedTargets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mappedTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTagMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"valid", `[{"source": "^v(.*)$", "destination": "foo/v{{index .Match 1}}"}]`, false},
+		{"invalid json", `{`, true},
+		{"invalid source", `[{"source": "^v(", "destination": "foo"}]`, true},
+		{"empty destination", `[{"source": "^v"}]`, true},
+		{"invalid destination", `[{"source": "^v", "destination": "{{.Tag"}]`, true},
+		{"invalid prereleases", `[{"source": "^v", "destination": "{{.Tag}}", "prereleases": "never"}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseTagMappings(tt.json); (err != nil) != tt.wantErr {
+				t.Errorf("parseTagMappings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_prefixTagOptions_targets(t *testing.T) {
+	tests := []struct {
+		name string
+		opts prefixTagOptions
+		tag  string
+		want *tagTargets
+	}{
+		{"prefix", prefixTagOptions{prefix: "kcp-"}, "v1.2.3", &tagTargets{Tags: []string{"kcp-1.2.3"}, SearchTag: "kcp-1.2.3"}},
+		{"semver tags", prefixTagOptions{prefix: "kcp-", publishSemverTags: true, semverTagsBase: "v0"}, "v1.2.3", &tagTargets{Tags: []string{"v0.2.3", "kcp-1.2.3"}, SearchTag: "kcp-1.2.3", Semver: true}},
+		{"v0 semver", prefixTagOptions{prefix: "kcp-", publishV0Semver: true}, "v1.2.3", &tagTargets{Tags: []string{"v0.2.3", "kcp-1.2.3"}, SearchTag: "kcp-1.2.3", Semver: true}},
+		{"skip non-semver", prefixTagOptions{prefix: "kcp-", skipNonSemverTags: true}, "release-1.2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.targets(tt.tag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("targets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// sourceTag is a source tag to be published.
+type sourceTag struct {
+	name string
+	// target is the tagged source commit.
+	target plumbing.Hash
+	// when is the date of the tag.
+	when    time.Time
+	targets *tagTargets
+}
+
+// tagPolicy selects the source tags published in a run.
+type tagPolicy struct {
+	// minDate skips tags older than this date, if not zero.
+	minDate time.Time
+	// maxTags is the maximum number of tags published per run, if positive.
+	maxTags int
+	// onlyNewer skips tags not newer than the last published tag.
+	onlyNewer bool
+}
+
+// parseTagDate parses a date as YYYY-MM-DD or in RFC 3339 format.
+func parseTagDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, must be YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// selectTags returns the candidates to publish, oldest first. lastPublished is
+// the newest source tag already published, or nil. Skipped tags are logged
+// with the reason.
+func (p tagPolicy) selectTags(candidates []sourceTag, lastPublished *sourceTag) []sourceTag {
+	sorted := append([]sourceTag(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].when.Equal(sorted[j].when) {
+			return sorted[i].when.Before(sorted[j].when)
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	var selected []sourceTag
+	for _, t := range sorted {
+		switch {
+		case !p.minDate.IsZero() && t.when.Before(p.minDate):
+			fmt.Printf("Skipping tag %s from %v: older than the minimum tag date %v.\n", t.name, t.when, p.minDate)
+		case p.onlyNewer && lastPublished != nil && !t.when.After(lastPublished.when):
+			fmt.Printf("Skipping tag %s from %v: not newer than the last published tag %s from %v.\n", t.name, t.when, lastPublished.name, lastPublished.when)
+		case p.maxTags > 0 && len(selected) >= p.maxTags:
+			fmt.Printf("Skipping tag %s from %v: limit of %d tags per run reached.\n", t.name, t.when, p.maxTags)
+		default:
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_selectTags(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	candidates := []sourceTag{
+		{name: "v1.3.0", when: day(3)},
+		{name: "v1.1.0", when: day(1)},
+		{name: "v1.2.0", when: day(2)},
+		{name: "v1.4.0", when: day(4)},
+	}
+	published := &sourceTag{name: "v1.1.1", when: day(2)}
+
+	tests := []struct {
+		name          string
+		policy        tagPolicy
+		lastPublished *sourceTag
+		want          []string
+	}{
+		{"no policy", tagPolicy{}, published, []string{"v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0"}},
+		{"min date", tagPolicy{minDate: day(2)}, nil, []string{"v1.2.0", "v1.3.0", "v1.4.0"}},
+		{"max tags", tagPolicy{maxTags: 2}, nil, []string{"v1.1.0", "v1.2.0"}},
+		{"only newer", tagPolicy{onlyNewer: true}, published, []string{"v1.3.0", "v1.4.0"}},
+		{"only newer without published", tagPolicy{onlyNewer: true}, nil, []string{"v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0"}},
+		{"combined", tagPolicy{minDate: day(2), maxTags: 1, onlyNewer: true}, published, []string{"v1.3.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, st := range tt.policy.selectTags(candidates, tt.lastPublished) {
+				got = append(got, st.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTagDate(t *testing.T) {
+	tests := []struct {
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2017-09-01", time.Date(2017, 9, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2017-09-01T12:00:00Z", time.Date(2017, 9, 1, 12, 0, 0, 0, time.UTC), false},
+		{"09/01/2017", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, err := parseTagDate(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTagDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTagDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Reasons of stale tags.
+const (
+	staleTagDeleted    = "deleted"
+	staleTagRetargeted = "retargeted"
+)
+
+// tagRecord is the source a destination tag was created from.
+type tagRecord struct {
+	tag          string
+	sourceTag    string
+	sourceCommit plumbing.Hash
+}
+
+// tagRecordsFileName returns the file the tag records of the given
+// destination repository and branch are stored in, next to the commit
+// mappings.
+func tagRecordsFileName(dir, repo, branch string) string {
+	return filepath.Join(dir, fmt.Sprintf("tags-%s-%s", repo, strings.ReplaceAll(branch, "/", "_")))
+}
+
+// loadTagRecords reads the tag records from fname, by the hash of the
+// destination tag. Records are kept by hash and not by name, such that a tag
+// recreated locally, but not pushed, does not hide the one at origin. It
+// returns no records if the file does not exist.
+func loadTagRecords(fname string) (map[plumbing.Hash]tagRecord, error) {
+	records := map[plumbing.Hash]tagRecord{}
+	f, err := os.Open(fname)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid line %q in %s", scanner.Text(), fname)
+		}
+		records[plumbing.NewHash(fields[0])] = tagRecord{tag: fields[1], sourceTag: fields[2], sourceCommit: plumbing.NewHash(fields[3])}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fname, err)
+	}
+	return records, nil
+}
+
+// saveTagRecords writes the tag records to fname.
+func saveTagRecords(fname string, records map[plumbing.Hash]tagRecord) error {
+	hashes := make([]plumbing.Hash, 0, len(records))
+	for h := range records {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		if records[hashes[i]].tag != records[hashes[j]].tag {
+			return records[hashes[i]].tag < records[hashes[j]].tag
+		}
+		return hashes[i].String() < hashes[j].String()
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, h := range hashes {
+		rec := records[h]
+		fmt.Fprintf(w, "%s %s %s %s\n", h, rec.tag, rec.sourceTag, rec.sourceCommit)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fname)
+}
+
+// staleTag is a destination tag whose source tag was deleted or moved to
+// another commit.
+type staleTag struct {
+	record tagRecord
+	// reason is deleted or retargeted.
+	reason string
+	// newCommit is the commit the source tag points to now, if retargeted.
+	newCommit plumbing.Hash
+}
+
+func (s staleTag) String() string {
+	if s.reason == staleTagDeleted {
+		return fmt.Sprintf("tag %s is stale: source tag %s was deleted", s.record.tag, s.record.sourceTag)
+	}
+	return fmt.Sprintf("tag %s is stale: source tag %s moved from %s to %s", s.record.tag, s.record.sourceTag, s.record.sourceCommit, s.newCommit)
+}
+
+// reconcileTags returns the recorded tags at origin whose source tag is not in
+// srcTags anymore, or points to another commit, sorted by tag. srcTags maps
+// the source tags to the tagged commits, originTags the tags at origin to
+// their hashes.
+func reconcileTags(records map[plumbing.Hash]tagRecord, srcTags, originTags map[string]plumbing.Hash) []staleTag {
+	var stale []staleTag
+	for name, h := range originTags {
+		rec, ok := records[h]
+		if !ok || rec.tag != name {
+			continue
+		}
+		commit, ok := srcTags[rec.sourceTag]
+		switch {
+		case !ok:
+			stale = append(stale, staleTag{record: rec, reason: staleTagDeleted})
+		case commit != rec.sourceCommit:
+			stale = append(stale, staleTag{record: rec, reason: staleTagRetargeted, newCommit: commit})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].record.tag < stale[j].record.tag })
+	return stale
+}
+
+// staleTagComments returns the push script comments reporting the stale
+// tags, like "# stale-tag <tag> <source-tag> <reason> <rewritten>". Deleted
+// tags are rewritten if rewrite is true, retargeted tags only if they were
+// recreated, i.e. not if the source tag moved off the source branch.
+func staleTagComments(stale []staleTag, rewrite bool, recreated []string) []string {
+	comments := make([]string, 0, len(stale))
+	for _, s := range stale {
+		rewritten := rewrite
+		if s.reason == staleTagRetargeted {
+			rewritten = rewrite && slices.Contains(recreated, s.record.tag)
+		}
+		comments = append(comments, fmt.Sprintf("# stale-tag %s %s %s %t", s.record.tag, s.record.sourceTag, s.reason, rewritten))
+	}
+	return comments
+}
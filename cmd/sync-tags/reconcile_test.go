@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func Test_reconcileTags(t *testing.T) {
+	var (
+		commitA = plumbing.NewHash("1111111111111111111111111111111111111111")
+		commitB = plumbing.NewHash("2222222222222222222222222222222222222222")
+		tag1    = plumbing.NewHash("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		tag2    = plumbing.NewHash("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+		tag3    = plumbing.NewHash("cccccccccccccccccccccccccccccccccccccccc")
+		tag4    = plumbing.NewHash("dddddddddddddddddddddddddddddddddddddddd")
+	)
+	records := map[plumbing.Hash]tagRecord{
+		tag1: {tag: "v0.1.0", sourceTag: "v1.1.0", sourceCommit: commitA},
+		tag2: {tag: "v0.2.0", sourceTag: "v1.2.0", sourceCommit: commitA},
+		tag3: {tag: "v0.3.0", sourceTag: "v1.3.0", sourceCommit: commitA},
+		// recreated locally, but not pushed
+		tag4: {tag: "v0.2.0", sourceTag: "v1.2.0", sourceCommit: commitB},
+	}
+	srcTags := map[string]plumbing.Hash{
+		"v1.1.0": commitA,
+		"v1.2.0": commitB,
+	}
+	originTags := map[string]plumbing.Hash{
+		"v0.1.0": tag1,
+		"v0.2.0": tag2,
+		"v0.3.0": tag3,
+		// not recorded, e.g. created manually
+		"v0.4.0": commitB,
+	}
+
+	got := reconcileTags(records, srcTags, originTags)
+	want := []staleTag{
+		{record: records[tag2], reason: staleTagRetargeted, newCommit: commitB},
+		{record: records[tag3], reason: staleTagDeleted},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reconcileTags() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		rewrite   bool
+		recreated []string
+		want      []string
+	}{
+		{"report only", false, nil, []string{
+			"# stale-tag v0.2.0 v1.2.0 retargeted false",
+			"# stale-tag v0.3.0 v1.3.0 deleted false",
+		}},
+		{"rewritten", true, []string{"v0.2.0"}, []string{
+			"# stale-tag v0.2.0 v1.2.0 retargeted true",
+			"# stale-tag v0.3.0 v1.3.0 deleted true",
+		}},
+		// the source tag moved off the source branch, hence is not recreated
+		{"retargeted off the branch", true, nil, []string{
+			"# stale-tag v0.2.0 v1.2.0 retargeted false",
+			"# stale-tag v0.3.0 v1.3.0 deleted true",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staleTagComments(want, tt.rewrite, tt.recreated); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staleTagComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tagRecords(t *testing.T) {
+	fname := tagRecordsFileName(t.TempDir(), "api", "release/1.0")
+	if filepath.Base(fname) != "tags-api-release_1.0" {
+		t.Errorf("unexpected file name %s", fname)
+	}
+
+	records, err := loadTagRecords(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records for a missing file, got %v", records)
+	}
+
+	records[plumbing.NewHash("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")] = tagRecord{
+		tag:          "v0.1.0",
+		sourceTag:    "v1.1.0",
+		sourceCommit: plumbing.NewHash("1111111111111111111111111111111111111111"),
+	}
+	if err := saveTagRecords(fname, records); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadTagRecords(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, records) {
+		t.Errorf("loadTagRecords() = %v, want %v", loaded, records)
+	}
+}
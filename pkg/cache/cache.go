@@ -17,15 +17,23 @@ limitations under the License.
 package cache
 
 import (
+	"sync"
+
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
-var globalCommitCache = map[plumbing.Hash]*object.Commit{}
+var (
+	globalCommitCacheLock sync.Mutex
+	globalCommitCache     = map[plumbing.Hash]*object.Commit{}
+)
 
 func CommitObject(r *gogit.Repository, hash plumbing.Hash) (*object.Commit, error) {
-	if c, found := globalCommitCache[hash]; found {
+	globalCommitCacheLock.Lock()
+	c, found := globalCommitCache[hash]
+	globalCommitCacheLock.Unlock()
+	if found {
 		if c == nil {
 			return nil, plumbing.ErrObjectNotFound
 		}
@@ -33,6 +41,8 @@ func CommitObject(r *gogit.Repository, hash plumbing.Hash) (*object.Commit, erro
 	}
 
 	c, err := r.CommitObject(hash)
+	globalCommitCacheLock.Lock()
 	globalCommitCache[hash] = c
+	globalCommitCacheLock.Unlock()
 	return c, err
 }
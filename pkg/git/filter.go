@@ -0,0 +1,629 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"k8s.io/publishing-bot/pkg/cache"
+)
+
+// FilterOptions configures FilterBranch.
+type FilterOptions struct {
+	// CommitMsgTag is the trailer added to every rewritten commit message,
+	// pointing back to the source commit, e.g. "Kubernetes-commit".
+	CommitMsgTag string
+	// Dirs are the directories from the repo root to keep. A single directory
+	// becomes the root of the rewritten tree, like
+	// "git filter-branch --subdirectory-filter". With multiple directories,
+	// every directory keeps its path, unless Targets says otherwise.
+	Dirs []string
+	// Targets maps directories from Dirs to their path in the rewritten tree,
+	// "." being the root. Deeper targets take precedence over entries of the
+	// same name below shallower targets.
+	Targets map[string]string
+	// RecursiveDeletePatterns are ls-files patterns like "*/BUILD" or
+	// "Makefile", relative to the rewritten tree, which are removed from
+	// every rewritten commit.
+	RecursiveDeletePatterns []string
+}
+
+// FilterBranch rewrites the history reachable from the given refs to only
+// include the configured directories, similar to
+//
+//	git filter-branch --index-filter "git rm -q --cached --ignore-unmatch -r <patterns>" \
+//	    --msg-filter 'awk 1 && echo && echo "<tag>: ${GIT_COMMIT}"' \
+//	    --subdirectory-filter <dir> -- <refs>
+//
+// Commits which do not change the selected directories are dropped, merges
+// are simplified like "git rev-list --simplify-merges" does. Only local
+// branches (refs/heads/*) are updated, all other refs are used as starting
+// points only. A branch whose commits are all dropped is deleted.
+//
+// It returns the mapping from each visited source commit to its rewritten
+// commit, or plumbing.ZeroHash if there is no rewritten ancestor.
+func FilterBranch(r *gogit.Repository, opts FilterOptions, refs ...plumbing.ReferenceName) (map[plumbing.Hash]plumbing.Hash, error) {
+	if len(opts.Dirs) == 0 {
+		return nil, errors.New("no directories to filter given")
+	}
+
+	f := &filter{
+		r:          r,
+		opts:       opts,
+		mappings:   dirMappings(opts.Dirs, opts.Targets),
+		matcher:    newPathMatcher(opts.RecursiveDeletePatterns),
+		rewritten:  map[plumbing.Hash]plumbing.Hash{},
+		pruned:     map[prunedKey]plumbing.Hash{},
+		selected:   map[plumbing.Hash]plumbing.Hash{},
+		parents:    map[plumbing.Hash][]plumbing.Hash{},
+		generation: map[plumbing.Hash]int{},
+	}
+
+	tips := map[plumbing.ReferenceName]plumbing.Hash{}
+	for _, name := range refs {
+		ref, err := r.Reference(name, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		tips[name] = ref.Hash()
+	}
+
+	commits, err := f.topoOrder(tips)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		if err := f.rewrite(c); err != nil {
+			return nil, fmt.Errorf("failed to rewrite %s: %w", c.Hash, err)
+		}
+	}
+
+	for name, h := range tips {
+		if !name.IsBranch() {
+			continue
+		}
+		nh := f.rewritten[h]
+		if nh == plumbing.ZeroHash {
+			if err := r.Storer.RemoveReference(name); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+			continue
+		}
+		if err := r.Storer.SetReference(plumbing.NewHashReference(name, nh)); err != nil {
+			return nil, fmt.Errorf("failed to update %s to %s: %w", name, nh, err)
+		}
+	}
+
+	return f.rewritten, nil
+}
+
+// dirMapping maps a directory of the source tree to a path in the rewritten tree.
+type dirMapping struct {
+	source string
+	target string
+}
+
+func dirMappings(dirs []string, targets map[string]string) []dirMapping {
+	mappings := make([]dirMapping, 0, len(dirs))
+	for _, d := range dirs {
+		m := dirMapping{source: cleanDir(d), target: cleanDir(d)}
+		if len(dirs) == 1 {
+			m.target = "."
+		}
+		if t, ok := targets[d]; ok {
+			m.target = cleanDir(t)
+		}
+		mappings = append(mappings, m)
+	}
+
+	// insert shallow targets first such that deeper ones override their entries
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return targetDepth(mappings[i].target) < targetDepth(mappings[j].target)
+	})
+	return mappings
+}
+
+func targetDepth(target string) int {
+	if target == "." {
+		return 0
+	}
+	return strings.Count(target, "/") + 1
+}
+
+func cleanDir(d string) string {
+	return path.Clean(strings.Trim(d, "/"))
+}
+
+type prunedKey struct {
+	tree   plumbing.Hash
+	prefix string
+}
+
+type filter struct {
+	r        *gogit.Repository
+	opts     FilterOptions
+	mappings []dirMapping
+	matcher  pathMatcher
+
+	// rewritten maps source commits to rewritten commits.
+	rewritten map[plumbing.Hash]plumbing.Hash
+	// pruned caches trees with the recursive delete patterns applied.
+	pruned map[prunedKey]plumbing.Hash
+	// selected maps rewritten commits to their tree before applying the delete
+	// patterns. This is what decides whether a commit is TREESAME to its parents.
+	selected map[plumbing.Hash]plumbing.Hash
+	// parents and generation describe the rewritten commit graph.
+	parents    map[plumbing.Hash][]plumbing.Hash
+	generation map[plumbing.Hash]int
+}
+
+// topoOrder returns all commits reachable from the tips with parents before children.
+func (f *filter) topoOrder(tips map[plumbing.ReferenceName]plumbing.Hash) ([]*object.Commit, error) {
+	var order []*object.Commit
+	visited := map[plumbing.Hash]bool{}
+
+	type frame struct {
+		c    *object.Commit
+		next int
+	}
+	for _, tip := range tips {
+		if visited[tip] {
+			continue
+		}
+		c, err := cache.CommitObject(f.r, tip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", tip, err)
+		}
+		visited[tip] = true
+		stack := []frame{{c: c}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.next == len(top.c.ParentHashes) {
+				order = append(order, top.c)
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			ph := top.c.ParentHashes[top.next]
+			top.next++
+			if visited[ph] {
+				continue
+			}
+			visited[ph] = true
+			p, err := cache.CommitObject(f.r, ph)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s: %w", ph, err)
+			}
+			stack = append(stack, frame{c: p})
+		}
+	}
+	return order, nil
+}
+
+func (f *filter) rewrite(c *object.Commit) error {
+	selected, err := f.selectTree(c)
+	if err != nil {
+		return err
+	}
+
+	// map parents to their rewritten counterparts, dropping duplicates and
+	// parents without rewritten ancestor.
+	var parents []plumbing.Hash
+	seen := map[plumbing.Hash]bool{}
+	for _, ph := range c.ParentHashes {
+		np := f.rewritten[ph]
+		if np == plumbing.ZeroHash || seen[np] {
+			continue
+		}
+		seen[np] = true
+		parents = append(parents, np)
+	}
+	parents = f.simplifyParents(parents, selected)
+
+	if len(parents) == 0 && selected == plumbing.ZeroHash {
+		f.rewritten[c.Hash] = plumbing.ZeroHash
+		return nil
+	}
+	if len(parents) == 1 && f.selected[parents[0]] == selected {
+		f.rewritten[c.Hash] = parents[0]
+		return nil
+	}
+
+	tree, err := f.prune(selected, "")
+	if err != nil {
+		return err
+	}
+	nc := &object.Commit{
+		Author:       c.Author,
+		Committer:    c.Committer,
+		Message:      f.message(c),
+		TreeHash:     tree,
+		ParentHashes: parents,
+	}
+	nh, err := f.store(nc)
+	if err != nil {
+		return err
+	}
+
+	gen := 0
+	for _, p := range parents {
+		if g := f.generation[p]; g > gen {
+			gen = g
+		}
+	}
+	f.generation[nh] = gen + 1
+	f.parents[nh] = parents
+	f.selected[nh] = selected
+	f.rewritten[c.Hash] = nh
+
+	return nil
+}
+
+// simplifyParents removes parents which are ancestors of other parents, but
+// never all parents the commit is TREESAME to.
+func (f *filter) simplifyParents(parents []plumbing.Hash, selected plumbing.Hash) []plumbing.Hash {
+	if len(parents) < 2 {
+		return parents
+	}
+
+	var result, treesame []plumbing.Hash
+	for i, p := range parents {
+		redundant := false
+		for j, q := range parents {
+			if i != j && f.isAncestor(p, q) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, p)
+		} else if f.selected[p] == selected {
+			treesame = append(treesame, p)
+		}
+	}
+
+	for _, p := range result {
+		if f.selected[p] == selected {
+			return result
+		}
+	}
+	if len(treesame) > 0 {
+		result = append(result, treesame[0])
+	}
+	return result
+}
+
+// isAncestor returns true if a is a proper ancestor of b in the rewritten graph.
+func (f *filter) isAncestor(a, b plumbing.Hash) bool {
+	minGen := f.generation[a]
+	if f.generation[b] <= minGen {
+		return false
+	}
+	visited := map[plumbing.Hash]bool{}
+	queue := []plumbing.Hash{b}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		for _, p := range f.parents[h] {
+			if p == a {
+				return true
+			}
+			if visited[p] || f.generation[p] <= minGen {
+				continue
+			}
+			visited[p] = true
+			queue = append(queue, p)
+		}
+	}
+	return false
+}
+
+func (f *filter) message(c *object.Commit) string {
+	msg := c.Message
+	if msg != "" && !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	return fmt.Sprintf("%s\n%s: %s\n", msg, f.opts.CommitMsgTag, c.Hash)
+}
+
+// selectTree returns the tree of c with only the mapped directories, or
+// plumbing.ZeroHash if none of them exist.
+func (f *filter) selectTree(c *object.Commit) (plumbing.Hash, error) {
+	root, err := object.GetTree(f.r.Storer, c.TreeHash)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get tree %s: %w", c.TreeHash, err)
+	}
+
+	if len(f.mappings) == 1 && f.mappings[0].target == "." {
+		return subtreeHash(root, f.mappings[0].source)
+	}
+
+	n := &treeNode{}
+	for _, m := range f.mappings {
+		h, err := subtreeHash(root, m.source)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if h == plumbing.ZeroHash {
+			continue
+		}
+		if err := f.insert(n, m.target, h); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	return f.writeNode(n)
+}
+
+func subtreeHash(root *object.Tree, dir string) (plumbing.Hash, error) {
+	if dir == "." {
+		if len(root.Entries) == 0 {
+			return plumbing.ZeroHash, nil
+		}
+		return root.Hash, nil
+	}
+	e, err := root.FindEntry(dir)
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to look up %s: %w", dir, err)
+	}
+	if e.Mode != filemode.Dir {
+		return plumbing.ZeroHash, nil
+	}
+	return e.Hash, nil
+}
+
+// treeNode is a tree under construction. Entries of later inserted
+// directories replace those of earlier ones.
+type treeNode struct {
+	entries  map[string]object.TreeEntry
+	children map[string]*treeNode
+}
+
+func (f *filter) insert(n *treeNode, target string, h plumbing.Hash) error {
+	if target == "." {
+		t, err := object.GetTree(f.r.Storer, h)
+		if err != nil {
+			return fmt.Errorf("failed to get tree %s: %w", h, err)
+		}
+		for _, e := range t.Entries {
+			n.setEntry(e)
+		}
+		return nil
+	}
+
+	parts := strings.Split(target, "/")
+	for _, name := range parts[:len(parts)-1] {
+		child, err := f.child(n, name)
+		if err != nil {
+			return err
+		}
+		n = child
+	}
+	n.setEntry(object.TreeEntry{Name: parts[len(parts)-1], Mode: filemode.Dir, Hash: h})
+	return nil
+}
+
+func (n *treeNode) setEntry(e object.TreeEntry) {
+	if n.entries == nil {
+		n.entries = map[string]object.TreeEntry{}
+	}
+	delete(n.children, e.Name)
+	n.entries[e.Name] = e
+}
+
+// child returns the sub-node with the given name, expanding an existing
+// directory entry of that name.
+func (f *filter) child(n *treeNode, name string) (*treeNode, error) {
+	if c, ok := n.children[name]; ok {
+		return c, nil
+	}
+	c := &treeNode{}
+	if e, ok := n.entries[name]; ok {
+		if e.Mode == filemode.Dir {
+			if err := f.insert(c, ".", e.Hash); err != nil {
+				return nil, err
+			}
+		}
+		delete(n.entries, name)
+	}
+	if n.children == nil {
+		n.children = map[string]*treeNode{}
+	}
+	n.children[name] = c
+	return c, nil
+}
+
+func (f *filter) writeNode(n *treeNode) (plumbing.Hash, error) {
+	entries := make([]object.TreeEntry, 0, len(n.entries)+len(n.children))
+	for _, e := range n.entries {
+		entries = append(entries, e)
+	}
+	for name, c := range n.children {
+		h, err := f.writeNode(c)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if h != plumbing.ZeroHash {
+			entries = append(entries, object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: h})
+		}
+	}
+	return f.writeTree(entries)
+}
+
+// writeTree stores a tree with the given entries. It returns plumbing.ZeroHash
+// for an empty tree as git does not track empty directories.
+func (f *filter) writeTree(entries []object.TreeEntry) (plumbing.Hash, error) {
+	if len(entries) == 0 {
+		return plumbing.ZeroHash, nil
+	}
+	sort.Sort(object.TreeEntrySorter(entries))
+	return f.store(&object.Tree{Entries: entries})
+}
+
+// prune applies the recursive delete patterns to the given tree. The prefix
+// is the path of the tree in the rewritten commit.
+func (f *filter) prune(h plumbing.Hash, prefix string) (plumbing.Hash, error) {
+	if h == plumbing.ZeroHash {
+		return f.store(&object.Tree{})
+	}
+	if f.matcher.empty() {
+		return h, nil
+	}
+
+	key := prunedKey{tree: h, prefix: prefix}
+	if ph, ok := f.pruned[key]; ok {
+		return ph, nil
+	}
+
+	t, err := object.GetTree(f.r.Storer, h)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get tree %s: %w", h, err)
+	}
+	entries := make([]object.TreeEntry, 0, len(t.Entries))
+	changed := false
+	for _, e := range t.Entries {
+		p := path.Join(prefix, e.Name)
+		if f.matcher.match(p, e.Mode == filemode.Dir) {
+			changed = true
+			continue
+		}
+		if e.Mode == filemode.Dir {
+			sh, err := f.prune(e.Hash, p)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+			if sh != e.Hash {
+				changed = true
+				if sh == plumbing.ZeroHash {
+					continue
+				}
+				e.Hash = sh
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	ph := h
+	if changed {
+		if ph, err = f.writeTree(entries); err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if ph == plumbing.ZeroHash && prefix == "" {
+			if ph, err = f.store(&object.Tree{}); err != nil {
+				return plumbing.ZeroHash, err
+			}
+		}
+	}
+	f.pruned[key] = ph
+	return ph, nil
+}
+
+func (f *filter) store(o interface {
+	Encode(o plumbing.EncodedObject) error
+},
+) (plumbing.Hash, error) {
+	obj := f.r.Storer.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return f.r.Storer.SetEncodedObject(obj)
+}
+
+// pathMatcher matches paths like git pathspecs do: a literal pattern matches
+// the path itself and everything below it, "*" and "?" match any characters
+// including "/".
+type pathMatcher struct {
+	literals []string
+	globs    []*regexp.Regexp
+}
+
+func newPathMatcher(patterns []string) pathMatcher {
+	var m pathMatcher
+	for _, p := range patterns {
+		p = strings.Trim(p, "/")
+		if p == "" {
+			continue
+		}
+		if !strings.ContainsAny(p, "*?[") {
+			m.literals = append(m.literals, p)
+			continue
+		}
+		m.globs = append(m.globs, globToRegexp(p))
+	}
+	return m
+}
+
+func globToRegexp(glob string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			if j := strings.IndexByte(glob[i:], ']'); j > 0 {
+				class := glob[i+1 : i+j]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += j
+				continue
+			}
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+func (m pathMatcher) empty() bool {
+	return len(m.literals) == 0 && len(m.globs) == 0
+}
+
+// match returns true if p is to be deleted. Globs only match files, as the
+// git index does not contain directories.
+func (m pathMatcher) match(p string, isDir bool) bool {
+	for _, l := range m.literals {
+		if p == l {
+			return true
+		}
+	}
+	if isDir {
+		return false
+	}
+	for _, g := range m.globs {
+		if g.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
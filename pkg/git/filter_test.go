@@ -0,0 +1,272 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// testRepo builds commits with the given files directly in the object store.
+type testRepo struct {
+	t *testing.T
+	r *gogit.Repository
+	f *filter
+	n int
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r, err := gogit.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, r: r, f: &filter{r: r}}
+}
+
+func (tr *testRepo) commit(files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	tr.t.Helper()
+	root := &treeNode{}
+	for p, content := range files {
+		obj := tr.r.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		w, err := obj.Writer()
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			tr.t.Fatal(err)
+		}
+		w.Close()
+		bh, err := tr.r.Storer.SetEncodedObject(obj)
+		if err != nil {
+			tr.t.Fatal(err)
+		}
+		n := root
+		parts := strings.Split(p, "/")
+		for _, name := range parts[:len(parts)-1] {
+			if n, err = tr.f.child(n, name); err != nil {
+				tr.t.Fatal(err)
+			}
+		}
+		n.setEntry(object.TreeEntry{Name: parts[len(parts)-1], Mode: 0o100644, Hash: bh})
+	}
+	th, err := tr.f.writeNode(root)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	if th == plumbing.ZeroHash {
+		if th, err = tr.f.store(&object.Tree{}); err != nil {
+			tr.t.Fatal(err)
+		}
+	}
+
+	tr.n++
+	sig := object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(int64(tr.n), 0)}
+	h, err := tr.f.store(&object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      fmt.Sprintf("commit %d", tr.n),
+		TreeHash:     th,
+		ParentHashes: parents,
+	})
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return h
+}
+
+func (tr *testRepo) branch(name string, h plumbing.Hash) plumbing.ReferenceName {
+	tr.t.Helper()
+	ref := plumbing.NewBranchReferenceName(name)
+	if err := tr.r.Storer.SetReference(plumbing.NewHashReference(ref, h)); err != nil {
+		tr.t.Fatal(err)
+	}
+	return ref
+}
+
+func (tr *testRepo) files(h plumbing.Hash) []string {
+	tr.t.Helper()
+	c, err := tr.r.CommitObject(h)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	t, err := c.Tree()
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	var files []string
+	if err := t.Files().ForEach(func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	}); err != nil {
+		tr.t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestFilterBranch(t *testing.T) {
+	tr := newTestRepo(t)
+	a := tr.commit(map[string]string{"README": "a"})
+	b := tr.commit(map[string]string{"README": "a", "staging/foo/a.go": "a", "staging/foo/BUILD": "x"}, a)
+	c := tr.commit(map[string]string{"README": "c", "staging/foo/a.go": "a", "staging/foo/BUILD": "x"}, b)
+	d := tr.commit(map[string]string{"README": "c", "staging/foo/a.go": "d", "staging/foo/BUILD": "x", "staging/bar/b.go": "d"}, c)
+	ref := tr.branch("filtered-branch", d)
+
+	mapping, err := FilterBranch(tr.r, FilterOptions{
+		CommitMsgTag:            "Kubernetes-commit",
+		Dirs:                    []string{"staging/foo"},
+		RecursiveDeletePatterns: []string{"BUILD", "*/BUILD"},
+	}, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mapping[a] != plumbing.ZeroHash {
+		t.Errorf("expected commit without the directory to be dropped, got %s", mapping[a])
+	}
+	if mapping[c] != mapping[b] {
+		t.Errorf("expected commit not touching the directory to map to its parent")
+	}
+	head, err := tr.r.Reference(ref, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != mapping[d] {
+		t.Errorf("expected %s to point to %s, got %s", ref, mapping[d], head.Hash())
+	}
+
+	nd, err := tr.r.CommitObject(mapping[d])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.ParentHashes) != 1 || nd.ParentHashes[0] != mapping[b] {
+		t.Errorf("unexpected parents %v, expected %s", nd.ParentHashes, mapping[b])
+	}
+	if got := SourceHash(nd, "Kubernetes-commit"); got != d {
+		t.Errorf("expected source hash %s, got %s", d, got)
+	}
+	if want := fmt.Sprintf("commit 4\n\nKubernetes-commit: %s\n", d); nd.Message != want {
+		t.Errorf("unexpected message %q, expected %q", nd.Message, want)
+	}
+	if got, want := tr.files(mapping[d]), []string{"a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected files %v, expected %v", got, want)
+	}
+}
+
+func TestFilterBranchMultipleDirs(t *testing.T) {
+	tr := newTestRepo(t)
+	a := tr.commit(map[string]string{"staging/foo/a.go": "a", "hack/tools/t.go": "a", "other": "a"})
+	b := tr.commit(map[string]string{"staging/foo/a.go": "a", "hack/tools/t.go": "b", "other": "a"}, a)
+	ref := tr.branch("filtered-branch", b)
+
+	mapping, err := FilterBranch(tr.r, FilterOptions{
+		CommitMsgTag: "Kubernetes-commit",
+		Dirs:         []string{"staging/foo", "hack/tools"},
+	}, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping[a] == mapping[b] {
+		t.Errorf("expected commit changing the second directory to be kept")
+	}
+	if got, want := tr.files(mapping[b]), []string{"hack/tools/t.go", "staging/foo/a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected files %v, expected %v", got, want)
+	}
+}
+
+func TestFilterBranchTargets(t *testing.T) {
+	tr := newTestRepo(t)
+	a := tr.commit(map[string]string{"staging/src/foo/a.go": "a", "staging/src/foo/hack/old.sh": "a", "hack/tools/t.go": "a"})
+	ref := tr.branch("filtered-branch", a)
+
+	mapping, err := FilterBranch(tr.r, FilterOptions{
+		CommitMsgTag: "Kubernetes-commit",
+		Dirs:         []string{"hack/tools", "staging/src/foo"},
+		Targets:      map[string]string{"staging/src/foo": ".", "hack/tools": "hack"},
+	}, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tr.files(mapping[a]), []string{"a.go", "hack/t.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected files %v, expected %v", got, want)
+	}
+}
+
+func TestFilterBranchMerges(t *testing.T) {
+	tr := newTestRepo(t)
+	base := tr.commit(map[string]string{"dir/a": "a"})
+	// a feature branch which does not touch dir is dropped completely
+	other := tr.commit(map[string]string{"dir/a": "a", "x": "x"}, base)
+	m1 := tr.commit(map[string]string{"dir/a": "a", "x": "x"}, base, other)
+	// a feature branch touching dir keeps its merge
+	feature := tr.commit(map[string]string{"dir/a": "a", "dir/b": "b", "x": "x"}, m1)
+	main := tr.commit(map[string]string{"dir/a": "main", "x": "x"}, m1)
+	m2 := tr.commit(map[string]string{"dir/a": "main", "dir/b": "b", "x": "x"}, main, feature)
+	ref := tr.branch("filtered-branch", m2)
+
+	mapping, err := FilterBranch(tr.r, FilterOptions{CommitMsgTag: "Kubernetes-commit", Dirs: []string{"dir"}}, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping[m1] != mapping[base] || mapping[other] != mapping[base] {
+		t.Errorf("expected merge of an untouched branch to be dropped")
+	}
+	nm2, err := tr.r.CommitObject(mapping[m2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []plumbing.Hash{mapping[main], mapping[feature]}; !reflect.DeepEqual(nm2.ParentHashes, want) {
+		t.Errorf("unexpected merge parents %v, expected %v", nm2.ParentHashes, want)
+	}
+}
+
+func TestPathMatcher(t *testing.T) {
+	m := newPathMatcher([]string{"*/BUILD", "*.ext", "pkg/foo.go", "Makefile", "vendor"})
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a/BUILD", false, true},
+		{"a/b/BUILD", false, true},
+		{"BUILD", false, false},
+		{"x.ext", false, true},
+		{"a/x.ext", false, true},
+		{"pkg/foo.go", false, true},
+		{"pkg/bar.go", false, false},
+		{"Makefile", false, true},
+		{"a/Makefile", false, false},
+		{"vendor", true, true},
+		{"a.ext", true, false},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -25,16 +25,29 @@ import (
 	"k8s.io/publishing-bot/pkg/cache"
 )
 
+// commitLookup resolves commit hashes, e.g. through the global commit cache.
+type commitLookup func(r *gogit.Repository, h plumbing.Hash) (*object.Commit, error)
+
+// uncachedCommit resolves commits without the global commit cache, which is
+// unbounded and only suited for short-lived processes.
+func uncachedCommit(r *gogit.Repository, h plumbing.Hash) (*object.Commit, error) {
+	return r.CommitObject(h)
+}
+
 // FirstParent returns the first parent of a commit. For a merge commit this
 // is the parent which is usually depicted on the left.
 func FirstParent(r *gogit.Repository, c *object.Commit) (*object.Commit, error) {
+	return firstParent(r, c, cache.CommitObject)
+}
+
+func firstParent(r *gogit.Repository, c *object.Commit, lookup commitLookup) (*object.Commit, error) {
 	if c == nil {
 		return nil, nil
 	}
 	if len(c.ParentHashes) == 0 {
 		return nil, nil
 	}
-	p, err := cache.CommitObject(r, c.ParentHashes[0])
+	p, err := lookup(r, c.ParentHashes[0])
 	if err != nil {
 		return nil, fmt.Errorf("failed to get %v: %w", c.ParentHashes[0], err)
 	}
@@ -44,23 +57,43 @@ func FirstParent(r *gogit.Repository, c *object.Commit) (*object.Commit, error)
 // FirstParentList visits the ancestors of c using the FirstParent func. It returns the list
 // of visited commits.
 func FirstParentList(r *gogit.Repository, c *object.Commit) ([]*object.Commit, error) {
+	l, _, err := firstParentListUntil(r, c, plumbing.ZeroHash, cache.CommitObject)
+	return l, err
+}
+
+// FirstParentListUntil is like FirstParentList, but stops before the given commit. It returns
+// false if stop is not a first-parent ancestor of c, i.e. if the whole history was visited.
+func FirstParentListUntil(r *gogit.Repository, c *object.Commit, stop plumbing.Hash) ([]*object.Commit, bool, error) {
+	return firstParentListUntil(r, c, stop, cache.CommitObject)
+}
+
+func firstParentListUntil(r *gogit.Repository, c *object.Commit, stop plumbing.Hash, lookup commitLookup) ([]*object.Commit, bool, error) {
 	l := []*object.Commit{}
 	for c != nil {
+		if c.Hash == stop {
+			return l, true, nil
+		}
 		l = append(l, c)
 
 		// continue with first parent if there is one
-		next, err := FirstParent(r, c)
+		next, err := firstParent(r, c, lookup)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get first parent of %s: %w", c.Hash, err)
+			return nil, false, fmt.Errorf("failed to get first parent of %s: %w", c.Hash, err)
 		}
 		c = next
 	}
-	return l, nil
+	return l, false, nil
 }
 
 // MergePoints creates a look-up table from feature branch commit hashes to their merge commits
 // onto the given mainline.
 func MergePoints(r *gogit.Repository, mainLine []*object.Commit) (map[plumbing.Hash]*object.Commit, error) {
+	return mergePoints(r, mainLine, nil, cache.CommitObject)
+}
+
+// mergePoints is like MergePoints, but does not visit commits for which known returns true,
+// e.g. because they are on an older part of the mainline.
+func mergePoints(r *gogit.Repository, mainLine []*object.Commit, known func(plumbing.Hash) bool, lookup commitLookup) (map[plumbing.Hash]*object.Commit, error) {
 	// create lookup table for the position in mainLine
 	mainLinePos := map[plumbing.Hash]int{}
 	for i, c := range mainLine {
@@ -77,6 +110,9 @@ func MergePoints(r *gogit.Repository, mainLine []*object.Commit) (map[plumbing.H
 		if _, isOnMainLine := mainLinePos[h]; isOnMainLine {
 			return nil
 		}
+		if known != nil && known(h) {
+			return nil
+		}
 
 		// was h seen before as descendent of a mainline commit? It must have had
 		// a better position as we saw it earlier.
@@ -90,7 +126,7 @@ func MergePoints(r *gogit.Repository, mainLine []*object.Commit) (map[plumbing.H
 		c := seen[h]
 		if c == nil {
 			var err error
-			c, err = cache.CommitObject(r, h)
+			c, err = lookup(r, h)
 			if err != nil {
 				return fmt.Errorf("failed to get %s: %w", h.String(), err)
 			}
@@ -23,6 +23,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/golang/glog"
+	"k8s.io/publishing-bot/pkg/cache"
 )
 
 // SourceCommitToDstCommits returns a mapping from all kube mainline commits
@@ -43,8 +44,12 @@ import (
 //	   |-B
 //	    `A - initial commit
 func SourceCommitToDstCommits(r *gogit.Repository, commitMsgTag string, dstFirstParents, srcFirstParents []*object.Commit) (map[plumbing.Hash]plumbing.Hash, error) {
+	return sourceCommitToDstCommits(r, commitMsgTag, dstFirstParents, srcFirstParents, cache.CommitObject)
+}
+
+func sourceCommitToDstCommits(r *gogit.Repository, commitMsgTag string, dstFirstParents, srcFirstParents []*object.Commit, lookup commitLookup) (map[plumbing.Hash]plumbing.Hash, error) {
 	// compute merge point table
-	kubeMergePoints, err := MergePoints(r, srcFirstParents)
+	kubeMergePoints, err := mergePoints(r, srcFirstParents, nil, lookup)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build merge point table: %w", err)
 	}
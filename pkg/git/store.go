@@ -0,0 +1,238 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Mapping is the source to destination commit mapping of one destination
+// branch, as computed by SourceCommitToDstCommits.
+type Mapping struct {
+	// SourceHead and DstHead are the branch heads the mapping was computed for.
+	SourceHead plumbing.Hash
+	DstHead    plumbing.Hash
+	// Mainline is the first-parent history of SourceHead, oldest first.
+	Mainline []plumbing.Hash
+	// Commits maps every mainline commit to its destination commit.
+	Commits map[plumbing.Hash]plumbing.Hash
+}
+
+// MappingStore persists commit mappings as plain files in a directory, one
+// file per destination repository and branch.
+type MappingStore struct {
+	dir string
+}
+
+// NewMappingStore returns a store keeping its files in dir.
+func NewMappingStore(dir string) *MappingStore {
+	return &MappingStore{dir: dir}
+}
+
+func (s *MappingStore) fileName(repo, branch string) string {
+	branch = strings.ReplaceAll(branch, "/", "_")
+	return filepath.Join(s.dir, fmt.Sprintf("mapping-%s-%s", repo, branch))
+}
+
+// Load reads the mapping of the given destination repository and branch. It
+// returns an empty mapping if none was stored yet.
+func (s *MappingStore) Load(repo, branch string) (*Mapping, error) {
+	m := &Mapping{Commits: map[plumbing.Hash]plumbing.Hash{}}
+
+	f, err := os.Open(s.fileName(repo, branch))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %q in %s", scanner.Text(), f.Name())
+		}
+		switch fields[0] {
+		case "source-head:":
+			m.SourceHead = plumbing.NewHash(fields[1])
+		case "dst-head:":
+			m.DstHead = plumbing.NewHash(fields[1])
+		default:
+			kh := plumbing.NewHash(fields[0])
+			m.Mainline = append(m.Mainline, kh)
+			m.Commits[kh] = plumbing.NewHash(fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name(), err)
+	}
+
+	return m, nil
+}
+
+// Save writes the mapping of the given destination repository and branch.
+func (s *MappingStore) Save(repo, branch string, m *Mapping) error {
+	fname := s.fileName(repo, branch)
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	fmt.Fprintf(w, "source-head: %s\n", m.SourceHead)
+	fmt.Fprintf(w, "dst-head: %s\n", m.DstHead)
+	for _, kh := range m.Mainline {
+		fmt.Fprintf(w, "%s %s\n", kh, m.Commits[kh])
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fname)
+}
+
+// UpdateMapping brings m up to date with the given source and destination
+// heads. Only the commits added since m was computed are walked. If either
+// branch was rewritten, or a new destination commit stems from a source commit
+// off the known mainline, the mapping is computed from scratch. It returns the
+// number of source commits added to the mainline.
+func UpdateMapping(r *gogit.Repository, commitMsgTag string, m *Mapping, srcHead, dstHead *object.Commit) (int, error) {
+	if m.SourceHead == srcHead.Hash && m.DstHead == dstHead.Hash {
+		return 0, nil
+	}
+
+	srcNew, srcFound, err := firstParentListUntil(r, srcHead, m.SourceHead, uncachedCommit)
+	if err != nil {
+		return 0, err
+	}
+	dstNew, dstFound, err := firstParentListUntil(r, dstHead, m.DstHead, uncachedCommit)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.SourceHead == plumbing.ZeroHash || m.DstHead == plumbing.ZeroHash || !srcFound || !dstFound {
+		return recomputeMapping(r, commitMsgTag, m, srcHead, dstHead)
+	}
+
+	// merge points of the new mainline commits, not walking into the known history
+	mainlinePos := make(map[plumbing.Hash]int, len(m.Mainline))
+	for i, kh := range m.Mainline {
+		mainlinePos[kh] = i
+	}
+	kubeMergePoints, err := mergePoints(r, srcNew, func(h plumbing.Hash) bool {
+		_, known := mainlinePos[h]
+		return known
+	}, uncachedCommit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build merge point table: %w", err)
+	}
+
+	// direct mappings from the new destination commits. These can point to
+	// known mainline commits if the destination branch lagged behind.
+	firstChanged := len(m.Mainline)
+	direct := map[plumbing.Hash]plumbing.Hash{}
+	for _, c := range dstNew {
+		kh := SourceHash(c, commitMsgTag)
+		if kh == plumbing.ZeroHash {
+			continue
+		}
+		mh := kh
+		if merge := kubeMergePoints[kh]; merge != nil {
+			mh = merge.Hash
+		} else if pos, known := mainlinePos[kh]; known {
+			firstChanged = min(firstChanged, pos)
+		} else {
+			// kh might be a feature branch commit merged into the known
+			// mainline, whose merge point is not known here.
+			return recomputeMapping(r, commitMsgTag, m, srcHead, dstHead)
+		}
+		// do not override, because we might have seen the actual merge before
+		if _, found := direct[mh]; !found {
+			direct[mh] = c.Hash
+		}
+	}
+
+	// fill up from the first changed commit, keeping the direct mappings of
+	// the known history, i.e. those which differ from their predecessor.
+	dst, prev := plumbing.ZeroHash, plumbing.ZeroHash
+	if firstChanged > 0 {
+		dst = m.Commits[m.Mainline[firstChanged-1]]
+		prev = dst
+	}
+	for i := firstChanged; i < len(m.Mainline); i++ {
+		kh := m.Mainline[i]
+		old := m.Commits[kh]
+		if dh, found := direct[kh]; found {
+			dst = dh
+		} else if old != prev {
+			dst = old
+		}
+		prev = old
+		m.Commits[kh] = dst
+	}
+	for i := len(srcNew) - 1; i >= 0; i-- {
+		kh := srcNew[i].Hash
+		if dh, found := direct[kh]; found {
+			dst = dh
+		}
+		m.Mainline = append(m.Mainline, kh)
+		m.Commits[kh] = dst
+	}
+
+	m.SourceHead = srcHead.Hash
+	m.DstHead = dstHead.Hash
+	return len(srcNew), nil
+}
+
+// recomputeMapping replaces m by the mapping of the given heads computed from
+// scratch. It returns the number of source mainline commits.
+func recomputeMapping(r *gogit.Repository, commitMsgTag string, m *Mapping, srcHead, dstHead *object.Commit) (int, error) {
+	srcFirstParents, _, err := firstParentListUntil(r, srcHead, plumbing.ZeroHash, uncachedCommit)
+	if err != nil {
+		return 0, err
+	}
+	dstFirstParents, _, err := firstParentListUntil(r, dstHead, plumbing.ZeroHash, uncachedCommit)
+	if err != nil {
+		return 0, err
+	}
+	commits, err := sourceCommitToDstCommits(r, commitMsgTag, dstFirstParents, srcFirstParents, uncachedCommit)
+	if err != nil {
+		return 0, err
+	}
+	m.Mainline = make([]plumbing.Hash, 0, len(srcFirstParents))
+	for i := len(srcFirstParents) - 1; i >= 0; i-- {
+		m.Mainline = append(m.Mainline, srcFirstParents[i].Hash)
+	}
+	m.Commits = commits
+	m.SourceHead = srcHead.Hash
+	m.DstHead = dstHead.Hash
+	return len(srcFirstParents), nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestUpdateMapping(t *testing.T) {
+	tr := newTestRepo(t)
+	filterOpts := FilterOptions{CommitMsgTag: "Kubernetes-commit", Dirs: []string{"dir"}}
+
+	c1 := tr.commit(map[string]string{"dir/a": "1"})
+	c2 := tr.commit(map[string]string{"dir/a": "1", "x": "2"}, c1)
+	c3 := tr.commit(map[string]string{"dir/a": "3", "x": "2"}, c2)
+	dstRef := tr.branch("dst", c3)
+	if _, err := FilterBranch(tr.r, filterOpts, dstRef); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(h plumbing.Hash) *object.Commit {
+		c, err := tr.r.CommitObject(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	head := func(ref plumbing.ReferenceName) *object.Commit {
+		r, err := tr.r.Reference(ref, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return commit(r.Hash())
+	}
+
+	store := NewMappingStore(t.TempDir())
+	m, err := store.Load("foo", "release/1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateMapping(tr.r, "Kubernetes-commit", m, commit(c3), head(dstRef)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("foo", "release/1.0", m); err != nil {
+		t.Fatal(err)
+	}
+
+	// extend the source with a merge and a commit not touching dir
+	feature := tr.commit(map[string]string{"dir/a": "3", "dir/b": "4", "x": "2"}, c3)
+	c5 := tr.commit(map[string]string{"dir/a": "3", "dir/b": "4", "x": "5"}, c3, feature)
+	c6 := tr.commit(map[string]string{"dir/a": "3", "dir/b": "4", "x": "6"}, c5)
+	dstRef = tr.branch("dst", c6)
+	if _, err := FilterBranch(tr.r, filterOpts, dstRef); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = store.Load("foo", "release/1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := UpdateMapping(tr.r, "Kubernetes-commit", m, commit(c6), head(dstRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 {
+		t.Errorf("expected 2 new mainline commits, got %d", added)
+	}
+
+	full := &Mapping{}
+	if _, err := UpdateMapping(tr.r, "Kubernetes-commit", full, commit(c6), head(dstRef)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Commits, full.Commits) {
+		t.Errorf("incremental mapping %v differs from full mapping %v", m.Commits, full.Commits)
+	}
+	if !reflect.DeepEqual(m.Mainline, full.Mainline) {
+		t.Errorf("incremental mainline %v differs from full mainline %v", m.Mainline, full.Mainline)
+	}
+}
+
+func TestUpdateMappingFeatureBranch(t *testing.T) {
+	tr := newTestRepo(t)
+	filterOpts := FilterOptions{CommitMsgTag: "Kubernetes-commit", Dirs: []string{"dir"}}
+
+	c1 := tr.commit(map[string]string{"dir/a": "1"})
+	c2 := tr.commit(map[string]string{"dir/a": "1", "x": "2"}, c1)
+	feature := tr.commit(map[string]string{"dir/a": "1", "dir/b": "3", "x": "2"}, c2)
+	c4 := tr.commit(map[string]string{"dir/a": "1", "dir/b": "3", "x": "2"}, c2, feature)
+	c5 := tr.commit(map[string]string{"dir/a": "1", "dir/b": "3", "x": "5"}, c4)
+
+	commit := func(h plumbing.Hash) *object.Commit {
+		c, err := tr.r.CommitObject(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	filtered := func(h plumbing.Hash) *object.Commit {
+		ref := tr.branch("dst", h)
+		if _, err := FilterBranch(tr.r, filterOpts, ref); err != nil {
+			t.Fatal(err)
+		}
+		r, err := tr.r.Reference(ref, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return commit(r.Hash())
+	}
+
+	// the destination lags behind the merged feature branch
+	m := &Mapping{}
+	if _, err := UpdateMapping(tr.r, "Kubernetes-commit", m, commit(c5), filtered(c2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the destination catches up with commits from the feature branch
+	dstHead := filtered(c5)
+	if _, err := UpdateMapping(tr.r, "Kubernetes-commit", m, commit(c5), dstHead); err != nil {
+		t.Fatal(err)
+	}
+
+	full := &Mapping{}
+	if _, err := UpdateMapping(tr.r, "Kubernetes-commit", full, commit(c5), dstHead); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Commits, full.Commits) {
+		t.Errorf("incremental mapping %v differs from full mapping %v", m.Commits, full.Commits)
+	}
+	if !reflect.DeepEqual(m.Mainline, full.Mainline) {
+		t.Errorf("incremental mainline %v differs from full mainline %v", m.Mainline, full.Mainline)
+	}
+}